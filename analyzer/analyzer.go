@@ -0,0 +1,243 @@
+// Package analyzer provides goinspect as a go/analysis Analyzer.
+//
+// The call graph of each package is exported as a fact (*CallGraph), so the edges across packages are composed through facts.
+// The functions reaching the forbidden callees, and the call cycles are reported.
+package analyzer
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/podhmo/goinspect"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+)
+
+const doc = `goinspect reports the functions reaching forbidden callees, and call cycles
+
+The callees are written as the subject IDs of goinspect,
+<pkgpath>.<name> for functions (e.g. os.Exit), and <pkgpath>.<recv>#<name> for methods (e.g. database/sql.DB#Exec).`
+
+// Rules is the configuration of the reported diagnostics.
+type Rules struct {
+	Forbidden []string // the forbidden callees, reported when a function reaches them (e.g. os.Exit)
+	Cycles    bool     // report the functions in call cycles
+}
+
+var defaultRules Rules
+
+// Analyzer is the analyzer configured with the flags (-forbidden, -cycles).
+var Analyzer = New(&defaultRules)
+
+func init() {
+	Analyzer.Flags.Var((*listFlag)(&defaultRules.Forbidden), "forbidden", "comma-separated forbidden callees (e.g. os.Exit,log.Fatal)")
+	Analyzer.Flags.BoolVar(&defaultRules.Cycles, "cycles", false, "report the functions in call cycles")
+}
+
+// New returns the analyzer with rules.
+func New(rules *Rules) *analysis.Analyzer {
+	return &analysis.Analyzer{
+		Name:      "goinspect",
+		Doc:       doc,
+		FactTypes: []analysis.Fact{new(CallGraph)},
+		Run: func(pass *analysis.Pass) (interface{}, error) {
+			return run(pass, rules)
+		},
+	}
+}
+
+// CallGraph is the call graph of a package, exported as a fact.
+// The edges are sorted by caller, so that the fact is encoded deterministically.
+type CallGraph struct {
+	Edges []Edge
+}
+
+// Edge is the calls of a function or method (the subject IDs of goinspect).
+type Edge struct {
+	Caller  string
+	Callees []string
+}
+
+func (*CallGraph) AFact() {}
+
+func (g *CallGraph) String() string {
+	lines := make([]string, len(g.Edges))
+	for i, e := range g.Edges {
+		lines[i] = e.Caller + " -> " + strings.Join(e.Callees, ", ")
+	}
+	return "CallGraph{" + strings.Join(lines, "; ") + "}"
+}
+
+func run(pass *analysis.Pass, rules *Rules) (interface{}, error) {
+	// the calls into the imported packages (including stdlib) are linked, as leaf nodes
+	var pkgpaths []string
+	seen := map[*types.Package]bool{}
+	var walk func(*types.Package)
+	walk = func(p *types.Package) {
+		if seen[p] {
+			return
+		}
+		seen[p] = true
+		pkgpaths = append(pkgpaths, p.Path())
+		for _, imported := range p.Imports() {
+			walk(imported)
+		}
+	}
+	walk(pass.Pkg)
+
+	c := &goinspect.Config{Fset: pass.Fset, PkgPath: pass.Pkg.Path(), IncludeUnexported: true}
+	g := goinspect.NewGraph()
+	scanner := goinspect.NewScanner(c, g, pkgpaths)
+	pkg := &packages.Package{ID: pass.Pkg.Path(), Name: pass.Pkg.Name(), PkgPath: pass.Pkg.Path(), Fset: pass.Fset, Syntax: pass.Files, Types: pass.Pkg, TypesInfo: pass.TypesInfo}
+	for _, f := range pass.Files {
+		if err := scanner.Scan(pkg, f); err != nil {
+			return nil, fmt.Errorf("scan %s: %w", pass.Fset.Position(f.Pos()).Filename, err)
+		}
+	}
+
+	fact := &CallGraph{}
+	var funcs []*goinspect.Node // the functions and methods declared in the package
+	g.Walk(func(n *goinspect.Node) {
+		if _, ok := n.Value.Decl.(*ast.FuncDecl); !ok {
+			return
+		}
+		funcs = append(funcs, n)
+		if len(n.To) == 0 {
+			return
+		}
+		e := Edge{Caller: n.Value.ID, Callees: make([]string, len(n.To))}
+		for i, next := range n.To {
+			e.Callees[i] = next.Value.ID
+		}
+		fact.Edges = append(fact.Edges, e)
+	})
+	sort.Slice(fact.Edges, func(i, j int) bool { return fact.Edges[i].Caller < fact.Edges[j].Caller })
+	if len(fact.Edges) > 0 {
+		pass.ExportPackageFact(fact)
+	}
+
+	// the edges of this package, and the dependencies (through facts, imported on demand)
+	imported := map[string]*types.Package{}
+	for p := range seen {
+		imported[p.Path()] = p
+	}
+	edges := &edgeMap{pass: pass, imported: imported, byPkg: map[string]map[string][]string{pass.Pkg.Path(): fact.lookup()}}
+
+	forbidden := make(map[string]bool, len(rules.Forbidden))
+	for _, id := range rules.Forbidden {
+		forbidden[id] = true
+	}
+	for _, n := range funcs {
+		if len(forbidden) > 0 {
+			if path := findPath(edges, n.Value.ID, func(id string) bool { return forbidden[id] }); path != nil {
+				pass.Reportf(n.Value.Object.Pos(), "%s reaches forbidden %s (%s)", n.Value.ID, path[len(path)-1], strings.Join(path, " -> "))
+			}
+		}
+		if rules.Cycles {
+			if path := findPath(edges, n.Value.ID, func(id string) bool { return id == n.Value.ID }); path != nil {
+				pass.Reportf(n.Value.Object.Pos(), "%s is in a call cycle (%s)", n.Value.ID, strings.Join(path, " -> "))
+			}
+		}
+	}
+	return nil, nil
+}
+
+// findPath returns the shortest call path from the callees of start to the node matched by goal, including start.
+func findPath(edges *edgeMap, start string, goal func(string) bool) []string {
+	parents := map[string]string{}
+	q := []string{start}
+	visited := map[string]bool{}
+	for len(q) > 0 {
+		id := q[0]
+		q = q[1:]
+		for _, next := range edges.callees(id) {
+			if visited[next] {
+				continue
+			}
+			visited[next] = true
+			parents[next] = id
+			if goal(next) {
+				path := []string{next}
+				for x := id; x != start; x = parents[x] {
+					path = append(path, x)
+				}
+				path = append(path, start)
+				for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+					path[i], path[j] = path[j], path[i]
+				}
+				return path
+			}
+			q = append(q, next)
+		}
+	}
+	return nil
+}
+
+func (g *CallGraph) lookup() map[string][]string {
+	m := make(map[string][]string, len(g.Edges))
+	for _, e := range g.Edges {
+		m[e.Caller] = e.Callees
+	}
+	return m
+}
+
+// edgeMap is the edges of the packages, the facts of the dependencies are imported on demand.
+type edgeMap struct {
+	pass     *analysis.Pass
+	imported map[string]*types.Package      // pkgpath -> the package, this package and its dependencies
+	byPkg    map[string]map[string][]string // pkgpath -> caller ID -> callee IDs
+}
+
+// callees returns the callee IDs of the function or method.
+func (m *edgeMap) callees(id string) []string {
+	pkgpath := m.pkgPathOf(id)
+	if pkgpath == "" {
+		return nil
+	}
+	edges, ok := m.byPkg[pkgpath]
+	if !ok {
+		var fact CallGraph
+		if m.pass.ImportPackageFact(m.imported[pkgpath], &fact) {
+			edges = fact.lookup()
+		}
+		m.byPkg[pkgpath] = edges
+	}
+	return edges[id]
+}
+
+// pkgPathOf returns the package path of the subject ID (e.g. gopkg.in/yaml.v3.Marshal -> gopkg.in/yaml.v3), or "" if the package is not imported.
+func (m *edgeMap) pkgPathOf(id string) string {
+	start := strings.LastIndex(id, "/") + 1
+	pkgpath := ""
+	for i := start; i < len(id); i++ {
+		if id[i] == '.' {
+			if _, ok := m.imported[id[:i]]; ok {
+				pkgpath = id[:i] // the longest
+			}
+		}
+	}
+	return pkgpath
+}
+
+// listFlag is the flag of comma-separated values.
+type listFlag []string
+
+func (f *listFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *listFlag) Set(v string) error {
+	*f = nil
+	for _, x := range strings.Split(v, ",") {
+		if x = strings.TrimSpace(x); x != "" {
+			*f = append(*f, x)
+		}
+	}
+	return nil
+}
+
+var _ flag.Value = (*listFlag)(nil)
@@ -0,0 +1,13 @@
+package analyzer_test
+
+import (
+	"testing"
+
+	"github.com/podhmo/goinspect/analyzer"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	a := analyzer.New(&analyzer.Rules{Forbidden: []string{"os.Exit"}, Cycles: true})
+	analysistest.Run(t, analysistest.TestData(), a, "a", "b")
+}
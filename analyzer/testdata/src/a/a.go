@@ -0,0 +1,24 @@
+package a // want package:`CallGraph{a.Ping -> a.Pong; a.Pong -> a.Ping; a.Run -> b.Safe, b.Fail; a.W#Loop -> a.W#Loop}`
+
+import "b"
+
+func Run() { // want `a.Run reaches forbidden os.Exit \(a.Run -> b.Fail -> b.Exit -> os.Exit\)`
+	b.Safe()
+	b.Fail()
+}
+
+func Ping(n int) { // want `a.Ping is in a call cycle \(a.Ping -> a.Pong -> a.Ping\)`
+	Pong(n - 1)
+}
+
+func Pong(n int) { // want `a.Pong is in a call cycle \(a.Pong -> a.Ping -> a.Pong\)`
+	if n > 0 {
+		Ping(n)
+	}
+}
+
+type W struct{}
+
+func (w *W) Loop() { // want `a.W#Loop is in a call cycle \(a.W#Loop -> a.W#Loop\)`
+	w.Loop()
+}
@@ -0,0 +1,13 @@
+package b // want package:`CallGraph{b.Exit -> os.Exit; b.Fail -> b.Exit}`
+
+import "os"
+
+func Exit(code int) { // want `b.Exit reaches forbidden os.Exit \(b.Exit -> os.Exit\)`
+	os.Exit(code)
+}
+
+func Fail() { // want `b.Fail reaches forbidden os.Exit \(b.Fail -> b.Exit -> os.Exit\)`
+	Exit(1)
+}
+
+func Safe() {}
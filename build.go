@@ -0,0 +1,64 @@
+package goinspect
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Build is a build configuration for loading packages.
+type Build struct {
+	GOOS   string
+	GOARCH string
+	Tags   []string
+}
+
+// ParseBuild parses the text representation of build configuration, "[GOOS/GOARCH][:tag1+tag2]" (e.g. linux/amd64, windows/amd64:integration, :integration+e2e).
+func ParseBuild(s string) (Build, error) {
+	var b Build
+	platform, tags, _ := strings.Cut(s, ":")
+	if platform != "" {
+		goos, goarch, ok := strings.Cut(platform, "/")
+		if !ok || goos == "" || goarch == "" {
+			return b, fmt.Errorf("invalid build configuration %q, must be [GOOS/GOARCH][:tag1+tag2]", s)
+		}
+		b.GOOS = goos
+		b.GOARCH = goarch
+	}
+	if tags != "" {
+		b.Tags = strings.Split(tags, "+")
+	}
+	return b, nil
+}
+
+func (b Build) String() string {
+	s := ""
+	if b.GOOS != "" || b.GOARCH != "" {
+		s = b.GOOS + "/" + b.GOARCH
+	}
+	if len(b.Tags) > 0 {
+		s += ":" + strings.Join(b.Tags, "+")
+	}
+	return s
+}
+
+// Apply sets the build flags and the environment variables of the build configuration, to cfg.
+func (b Build) Apply(cfg *packages.Config) {
+	if len(b.Tags) > 0 {
+		cfg.BuildFlags = append(cfg.BuildFlags, "-tags="+strings.Join(b.Tags, ","))
+	}
+	if b.GOOS == "" && b.GOARCH == "" {
+		return
+	}
+	if cfg.Env == nil {
+		cfg.Env = os.Environ()
+	}
+	if b.GOOS != "" {
+		cfg.Env = append(cfg.Env, "GOOS="+b.GOOS)
+	}
+	if b.GOARCH != "" {
+		cfg.Env = append(cfg.Env, "GOARCH="+b.GOARCH)
+	}
+}
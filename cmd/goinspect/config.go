@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/podhmo/flagstruct"
+	"gopkg.in/yaml.v3"
+)
+
+// configFilenames are the names of the config file, searched from the current directory up to the directory of go.mod.
+// JSON is read as YAML (a subset of YAML).
+var configFilenames = []string{".goinspect.yaml", ".goinspect.yml", ".goinspect.toml", ".goinspect.json"}
+
+// loadConfig sets the options in the config file (and the view of --view), as the defaults of the command line flags.
+//
+//	# .goinspect.yaml
+//	pkg: [./...]
+//	include-unexported: true
+//	effect:
+//	  io: [os.*, io.*]
+//	  db: database/sql.*
+//	views:
+//	  handlers:
+//	    only: [Handler.ServeHTTP]
+//	    expand-all: true
+//
+// The keys are the same as the flag names. The options of a view override the toplevel ones.
+// The mapping values are set as <key>=<value> (e.g. --effect io=os.*).
+// In .goinspect.toml, the mappings are tables (e.g. [effect], [views.handlers]).
+func loadConfig(options *Options, args []string) error {
+	path := lookupFlag(args, "config")
+	view := lookupFlag(args, "view")
+	if path == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		path = findConfig(cwd)
+	}
+	if path == "" {
+		if view != "" {
+			return fmt.Errorf("--view %q requires a config file (%s)", view, strings.Join(configFilenames, ", "))
+		}
+		return nil
+	}
+	options.Config = path
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config: %w", err)
+	}
+	var values map[string]interface{}
+	if filepath.Ext(path) == ".toml" {
+		if _, err := toml.Decode(string(b), &values); err != nil {
+			return fmt.Errorf("parse config %s: %w", path, err)
+		}
+	} else if err := yaml.Unmarshal(b, &values); err != nil {
+		return fmt.Errorf("parse config %s: %w", path, err)
+	}
+
+	views, err := configViews(values["views"])
+	if err != nil {
+		return fmt.Errorf("config %s: %w", path, err)
+	}
+	delete(values, "views")
+	if view != "" {
+		v, ok := views[view]
+		if !ok {
+			names := make([]string, 0, len(views))
+			for name := range views {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return fmt.Errorf("config %s: view %q is not found, (%s)", path, view, strings.Join(names, ", "))
+		}
+		for k, x := range v {
+			values[k] = x
+		}
+	}
+
+	fs := flagstruct.Build(options)
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if k == "config" || k == "view" || k == "show-config" || fs.Lookup(k) == nil {
+			return fmt.Errorf("config %s: unexpected option %q", path, k)
+		}
+		items, ok := values[k].([]interface{})
+		if m, isMap := values[k].(map[string]interface{}); isMap {
+			items, ok = configMapItems(m), true // e.g. effect: {io: [os.*, io.*]} -> --effect io=os.* --effect io=io.*
+		}
+		if !ok {
+			items = []interface{}{values[k]}
+		}
+		for _, x := range items {
+			if err := fs.Set(k, fmt.Sprint(x)); err != nil {
+				return fmt.Errorf("config %s: %s: %w", path, k, err)
+			}
+		}
+	}
+	return nil
+}
+
+// configMapItems returns the items of the mapping, <key>=<value>, an item for each value of the lists.
+func configMapItems(m map[string]interface{}) []interface{} {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var items []interface{}
+	for _, k := range keys {
+		values, ok := m[k].([]interface{})
+		if !ok {
+			values = []interface{}{m[k]}
+		}
+		for _, x := range values {
+			items = append(items, fmt.Sprintf("%s=%v", k, x))
+		}
+	}
+	return items
+}
+
+func configViews(v interface{}) (map[string]map[string]interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("views must be a mapping, but %T", v)
+	}
+	views := make(map[string]map[string]interface{}, len(m))
+	for name, x := range m {
+		view, ok := x.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("view %q must be a mapping, but %T", name, x)
+		}
+		views[name] = view
+	}
+	return views, nil
+}
+
+// findConfig returns the path of the config file in dir or its parents (up to the directory of go.mod), or "".
+func findConfig(dir string) string {
+	for {
+		for _, name := range configFilenames {
+			if path := filepath.Join(dir, name); isFile(path) {
+				return path
+			}
+		}
+		parent := filepath.Dir(dir)
+		if isFile(filepath.Join(dir, "go.mod")) || parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// lookupFlag returns the value of the flag in args (--<name> <value> or --<name>=<value>), before parsing.
+func lookupFlag(args []string, name string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if arg == "--"+name && i+1 < len(args) {
+			return args[i+1]
+		}
+		if v := strings.TrimPrefix(arg, "--"+name+"="); v != arg {
+			return v
+		}
+	}
+	return ""
+}
+
+// printConfig prints the effective options as YAML, in the format of the config file (--show-config).
+func printConfig(w io.Writer, options Options) error {
+	if options.Config != "" {
+		fmt.Fprintf(w, "# config: %s\n", options.Config)
+	}
+	if options.View != "" {
+		fmt.Fprintf(w, "# view: %s\n", options.View)
+	}
+
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	rt, rv := reflect.TypeOf(options), reflect.ValueOf(options)
+	for i := 0; i < rt.NumField(); i++ {
+		name := rt.Field(i).Tag.Get("flag")
+		if name == "" || name == "config" || name == "view" || name == "show-config" {
+			continue
+		}
+		v := rv.Field(i).Interface()
+		if d, ok := v.(time.Duration); ok {
+			v = d.String()
+		}
+		value := new(yaml.Node)
+		if err := value.Encode(v); err != nil {
+			return fmt.Errorf("encode %s: %w", name, err)
+		}
+		if value.Kind == yaml.SequenceNode {
+			value.Style = yaml.FlowStyle
+		}
+		doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, value)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/podhmo/flagstruct"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+	if err := os.WriteFile(path, []byte(strings.TrimSpace(content)+"\n"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+}
+
+func TestFindConfig(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".goinspect.yaml"), `pkg: [./...]`)
+	writeFile(t, filepath.Join(root, "mod", "go.mod"), `module example.com/mod`)
+	writeFile(t, filepath.Join(root, "mod", "configured", ".goinspect.yml"), `pkg: [./...]`)
+	writeFile(t, filepath.Join(root, "mod", "toml", ".goinspect.toml"), `pkg = ["./..."]`)
+
+	cases := []struct {
+		msg  string
+		dir  string
+		want string
+	}{
+		{msg: "current", dir: root, want: filepath.Join(root, ".goinspect.yaml")},
+		{msg: "parent", dir: filepath.Join(root, "mod", "configured", "sub"), want: filepath.Join(root, "mod", "configured", ".goinspect.yml")},
+		{msg: "toml", dir: filepath.Join(root, "mod", "toml"), want: filepath.Join(root, "mod", "toml", ".goinspect.toml")},
+		{msg: "stop-at-go.mod", dir: filepath.Join(root, "mod", "sub"), want: ""},
+		{msg: "go.mod", dir: filepath.Join(root, "mod"), want: ""},
+	}
+	for _, c := range cases {
+		t.Run(c.msg, func(t *testing.T) {
+			if got := findConfig(c.dir); got != c.want {
+				t.Errorf("findConfig(%q) = %q, want %q", c.dir, got, c.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	yamlConfig := `
+pkg: [./...]
+padding: "--"
+include-unexported: true
+effect:
+  io: [os.*, io.*]
+  db: database/sql.*
+views:
+  handlers:
+    padding: "@@"
+    only: [Handler.ServeHTTP]
+`
+	tomlConfig := `
+pkg = ["./..."]
+padding = "--"
+include-unexported = true
+
+[effect]
+io = ["os.*", "io.*"]
+db = "database/sql.*"
+
+[views.handlers]
+padding = "@@"
+only = ["Handler.ServeHTTP"]
+`
+
+	cases := []struct {
+		msg      string
+		filename string
+		config   string
+		args     []string
+		want     Options
+	}{
+		{
+			msg:      "toplevel",
+			filename: ".goinspect.yaml",
+			config:   yamlConfig,
+			want:     Options{Pkg: []string{"./..."}, Padding: "--", IncludeUnexported: true, Effect: []string{"db=database/sql.*", "io=os.*", "io=io.*"}},
+		},
+		{
+			msg:      "view",
+			filename: ".goinspect.yaml",
+			config:   yamlConfig,
+			args:     []string{"--view", "handlers"},
+			want:     Options{Pkg: []string{"./..."}, Padding: "@@", IncludeUnexported: true, Effect: []string{"db=database/sql.*", "io=os.*", "io=io.*"}, Only: []string{"Handler.ServeHTTP"}, View: "handlers"},
+		},
+		{
+			msg:      "flags",
+			filename: ".goinspect.yaml",
+			config:   yamlConfig,
+			args:     []string{"--view=handlers", "--padding", "::", "--effect", "net=net/http.Client.*", "--include-unexported=false"},
+			want:     Options{Pkg: []string{"./..."}, Padding: "::", Effect: []string{"net=net/http.Client.*"}, Only: []string{"Handler.ServeHTTP"}, View: "handlers"},
+		},
+		{
+			msg:      "toml",
+			filename: ".goinspect.toml",
+			config:   tomlConfig,
+			want:     Options{Pkg: []string{"./..."}, Padding: "--", IncludeUnexported: true, Effect: []string{"db=database/sql.*", "io=os.*", "io=io.*"}},
+		},
+		{
+			msg:      "toml-view",
+			filename: ".goinspect.toml",
+			config:   tomlConfig,
+			args:     []string{"--view", "handlers"},
+			want:     Options{Pkg: []string{"./..."}, Padding: "@@", IncludeUnexported: true, Effect: []string{"db=database/sql.*", "io=os.*", "io=io.*"}, Only: []string{"Handler.ServeHTTP"}, View: "handlers"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.msg, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), c.filename)
+			writeFile(t, path, c.config)
+			args := append([]string{"--config", path}, c.args...)
+
+			var got Options
+			if err := loadConfig(&got, args); err != nil {
+				t.Fatalf("unexpected error: %+v", err)
+			}
+			flagstruct.ParseArgs(&got, args) // the command line flags override the config file
+
+			c.want.Config = path
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Errorf("loadConfig() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadConfigError(t *testing.T) {
+	cases := []struct {
+		msg      string
+		filename string
+		config   string
+		args     []string
+		want     string // the substring of the error
+	}{
+		{msg: "unknown-key", filename: ".goinspect.yaml", config: `unknown: true`, want: `unexpected option "unknown"`},
+		{msg: "unknown-key-in-view", filename: ".goinspect.yaml", config: `views: {v: {unknown: true}}`, args: []string{"--view", "v"}, want: `unexpected option "unknown"`},
+		{msg: "unknown-view", filename: ".goinspect.yaml", config: `views: {v: {short: true}}`, args: []string{"--view", "w"}, want: `view "w" is not found, (v)`},
+		{msg: "unknown-key-toml", filename: ".goinspect.toml", config: `unknown = true`, want: `unexpected option "unknown"`},
+		{msg: "invalid-toml", filename: ".goinspect.toml", config: `short = `, want: "parse config"},
+	}
+	for _, c := range cases {
+		t.Run(c.msg, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), c.filename)
+			writeFile(t, path, c.config)
+
+			var options Options
+			err := loadConfig(&options, append([]string{"--config", path}, c.args...))
+			if err == nil {
+				t.Fatalf("loadConfig(), want error but got %+v", options)
+			}
+			if !strings.Contains(err.Error(), c.want) {
+				t.Errorf("loadConfig(), unexpected error %q, want %q", err, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"os"
+
+	"github.com/podhmo/goinspect"
+)
+
+// runLSP runs the language server over stdio (goinspect lsp).
+func runLSP(options Options) error {
+	c, g, err := scan(options)
+	if err != nil {
+		return err
+	}
+	return goinspect.NewLSPServer(c, g).Serve(os.Stdin, os.Stdout)
+}
@@ -10,11 +10,13 @@ import (
 	"log"
 	"os"
 	"os/exec"
-	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/podhmo/flagstruct"
 	"github.com/podhmo/goinspect"
+	"github.com/podhmo/goinspect/graph"
 	"golang.org/x/tools/go/packages"
 )
 
@@ -23,110 +25,259 @@ type Options struct {
 	ExpandAll         bool `flag:"expand-all" help:"expand all output"`
 	Short             bool `flag:"short" help:"use short representations of package path"`
 	OmitStruct        bool `flag:"omit-struct" help:"omit toplevel struct node in output"`
+	IncludeReferences bool `flag:"include-references" help:"include functions and methods passed as values (e.g. callbacks) in output"`
+	IncludeInit       bool `flag:"include-init" help:"include package-level variable initializers and init() functions as roots"`
+
+	KeepGoing bool `flag:"keep-going" help:"scan the packages even if they have errors (the affected nodes are marked as incomplete)"`
 
 	Debug   bool   `flag:"debug"`
 	Padding string `flag:"padding" help:"padding text"`
 
-	Pkg   string   `flag:"pkg" required:"true" help:"target package"`
+	Pkg   []string `flag:"pkg" help:"target packages (e.g. ./..., ./foo, github.com/<user>/<name>/bar)"`
+	Merge bool     `flag:"merge" help:"dump multiple target packages as one merged forest, instead of a section per package"`
 	Other []string `flag:"other" help:"the included packages in output"`
 	Only  []string `flag:"only" help:"selected symbols"`
+	Tests bool     `flag:"tests" help:"include test files, and show Test/Benchmark/Fuzz functions as roots"`
+
+	Registrar []string `flag:"registrar" help:"the registrars of entry points, <func>:<arg>, <type>#<method>:<arg> or <type>.<field> (e.g. github.com/spf13/cobra.Command.RunE), in addition to net/http (the entry points are marked in the tree)"`
+	Entries   bool     `flag:"entries" help:"show only the entry points (the functions and methods passed to the registrars, e.g. HTTP handlers) as roots"`
+
+	Reverse bool `flag:"reverse" help:"show the callers of selected symbols (--only), instead of the callees (--format text only)"`
+	Errors  bool `flag:"errors" help:"mark the calls returning error with how the error is handled (propagated, wrapped, handled, discarded, ignored)"`
+
+	Context bool `flag:"context" help:"show the calls passing context.Background() or context.TODO() instead of the received context, and the functions receiving a context only called without context, instead of the tree (exit status is 1, if found)"`
+
+	Locks bool `flag:"locks" help:"show the lock-order graph of sync.Mutex and sync.RWMutex along the call paths, with the inversions and the self-deadlocks, instead of the tree"`
+
+	Comm bool `flag:"comm" help:"show the goroutine and channel communication map (spawns, sends, receives), instead of the tree (--format text or mermaid)"`
+
+	Terminations bool     `flag:"terminations" help:"show the functions that may terminate the process or panic, with the call paths, instead of the tree (exit status is 1, if found)"`
+	Sinks        []string `flag:"sinks" help:"the terminating calls, panic, os.Exit, log.Fatal*, log.Panic*, log.Logger#Fatal* (globs, all if empty), the nodes reaching them are marked in the tree"`
+
+	Effect []string `flag:"effect" help:"the category of side effects, <name>=<pattern> (e.g. io=os.*, io=io.*, db=database/sql.*, net=net/http.Client.*, time=time.Now), the nodes reaching them are marked as [db,io]"`
+	Pure   bool     `flag:"pure" help:"show the functions without side effects of --effect, instead of the tree"`
+
+	Metrics bool `flag:"metrics" help:"show the metrics of functions (lines, cyclomatic complexity, params, results, nesting depth), and the totals reachable from them"`
+
+	Tags   string   `flag:"tags" help:"build tags (comma-separated), passed to go list"`
+	GOOS   string   `flag:"goos" help:"GOOS for loading packages"`
+	GOARCH string   `flag:"goarch" help:"GOARCH for loading packages"`
+	Build  []string `flag:"build" help:"scan and merge multiple build configurations, [GOOS/GOARCH][:tag1+tag2] (e.g. linux/amd64, windows/amd64:integration)"`
+
+	Format string `flag:"format" help:"output format (text, json, html)"`
+
+	ShowExternal    bool     `flag:"show-external" help:"show calls into packages outside the loaded set (e.g. stdlib) as leaf nodes"`
+	External        []string `flag:"external" help:"the shown external packages (e.g. database/sql, net/http/...), implies --show-external"`
+	ExcludeExternal []string `flag:"exclude-external" help:"the hidden external packages"`
+
+	Watch    bool          `flag:"watch" help:"watch the files of target packages, and dump again on changes"`
+	Diff     bool          `flag:"diff" help:"show the diff against the previous graph, with --watch"`
+	Interval time.Duration `flag:"interval" help:"polling interval of --watch"`
+
+	Cover     string `flag:"cover" help:"coverage profile (go test -coverprofile), the coverage of functions is shown with the nodes"`
+	Uncovered bool   `flag:"uncovered" help:"show only the paths reaching the uncovered functions (0%), with --cover"`
+
+	Profile        string  `flag:"profile" help:"pprof profile (e.g. cpu.pprof), the flat and cumulative values of functions are shown with the nodes"`
+	ProfileSample  string  `flag:"profile-sample" help:"sample type of --profile (e.g. cpu, alloc_space), the default sample type of the profile is used, if empty"`
+	MinCum         float64 `flag:"min-cum" help:"hide the functions under the percentage of the cumulative value (e.g. 1), with --profile"`
+	SortCost       bool    `flag:"sort-cost" help:"sort the nodes by the cumulative value, with --profile"`
+	ProfileMissing bool    `flag:"profile-missing" help:"show the call edges in --profile not found statically, instead of the tree"`
+
+	Addr string `flag:"addr" help:"listen address of goinspect serve (loopback only)"`
+
+	Config     string `flag:"config" help:"config file (default: .goinspect.yaml, .goinspect.yml, .goinspect.toml or .goinspect.json, searched up to the directory of go.mod)"`
+	View       string `flag:"view" help:"named view (preset of options) in the config file"`
+	ShowConfig bool   `flag:"show-config" help:"print the effective configuration, and exit"`
 }
 
 func main() {
-	options := &Options{Padding: "  "}
-	flagstruct.Parse(options)
+	options := &Options{Padding: "  ", Format: "text", Addr: "127.0.0.1:7878", Interval: time.Second}
 
-	if err := run(*options); err != nil {
+	// goinspect [command] [options]
+	cmd, args := "", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	}
+	if err := loadConfig(options, args); err != nil {
+		log.Fatalf("!! %+v", err)
+	}
+	flagstruct.ParseArgs(options, args) // the command line flags override the config file
+	if options.ShowConfig {
+		if err := printConfig(os.Stdout, *options); err != nil {
+			log.Fatalf("!! %+v", err)
+		}
+		return
+	}
+	if len(options.Pkg) == 0 {
+		log.Fatalf("!! --pkg is required (or pkg in the config file)")
+	}
+
+	var err error
+	switch cmd {
+	case "":
+		err = run(*options)
+	case "tui":
+		err = runTUI(*options)
+	case "serve":
+		err = runServe(*options)
+	case "lsp":
+		err = runLSP(*options)
+	case "stats":
+		err = runStats(*options)
+	default:
+		err = fmt.Errorf("unexpected command %q, (tui, serve, lsp, stats)", cmd)
+	}
+	if err != nil {
 		log.Fatalf("!! %+v", err)
 	}
 }
 
 func run(options Options) error {
-	fset := token.NewFileSet()
-	c := &goinspect.Config{
-		Fset:          fset,
-		PkgPath:       options.Pkg,
-		OtherPackages: options.Other,
-
-		Padding:           options.Padding,
-		IncludeUnexported: options.IncludeUnexported,
-		IncludeStruct:     !options.OmitStruct,
-		ExpandAll:         options.ExpandAll,
-		Debug:             options.Debug,
+	if options.Watch && options.Interval <= 0 {
+		return fmt.Errorf("unexpected interval %v with --watch, (positive duration)", options.Interval)
 	}
-
-	if strings.HasSuffix(c.PkgPath, "/...") {
-		c.OtherPackages = append(c.OtherPackages, c.PkgPath)
-		c.PkgPath = strings.TrimSuffix(c.PkgPath, "/...")
+	c, g, err := scan(options)
+	if err != nil {
+		return err
 	}
-
-	cfg := &packages.Config{
-		Fset: fset,
-		Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedImports | packages.NeedTypes | packages.NeedTypesSizes | packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedDeps,
+	if options.Watch {
+		return watch(options, c, g)
 	}
-	pkgs, err := packages.Load(cfg, append([]string{c.PkgPath}, c.OtherPackages...)...)
+	return dump(os.Stdout, options, c, g)
+}
+
+// runStats scans once and writes the summary of the graph, with the metrics of functions.
+func runStats(options Options) error {
+	options.Metrics = true
+	c, g, err := scan(options)
 	if err != nil {
-		return fmt.Errorf("load packages: %w", err)
+		return err
 	}
+	return goinspect.DumpStats(os.Stdout, c, g)
+}
 
-	{
-		goMod, err := goMod()
+// dump writes the scanned graph in the format of options.
+func dump(w io.Writer, options Options, c *goinspect.Config, g *goinspect.Graph) (err error) {
+	if options.ProfileMissing {
+		return goinspect.DumpProfileReport(w, c, c.ProfileReport)
+	}
+	if options.Context {
+		findings := goinspect.ContextFindings(g)
+		if err := goinspect.DumpContextFindings(w, c, findings); err != nil {
+			return fmt.Errorf("dump: %w", err)
+		}
+		if len(findings) > 0 {
+			return fmt.Errorf("%d context findings", len(findings))
+		}
+		return nil
+	}
+	if options.Locks {
+		if err := goinspect.DumpLockFindings(w, c, goinspect.LockFindings(g)); err != nil {
+			return fmt.Errorf("dump: %w", err)
+		}
+		return nil
+	}
+	if options.Comm {
+		switch options.Format {
+		case "text":
+			err = graph.RenderText(w, goinspect.CommMap(c, g))
+		case "mermaid":
+			err = graph.RenderMermaid(w, goinspect.CommMap(c, g))
+		default:
+			return fmt.Errorf("unexpected format %q with --comm, (text, mermaid)", options.Format)
+		}
 		if err != nil {
-			log.Println("go mod failed, %w", err)
+			return fmt.Errorf("dump: %w", err)
 		}
-
-		mods, err := modInfo(goMod)
-		if err == nil && len(mods) > 0 {
-			modInfo := mods[0]
-			// handling --short
-			if options.Short {
-				c.TrimPrefix = modInfo.Path
+		return nil
+	}
+	if options.Pure {
+		if err := goinspect.DumpPure(w, c, g); err != nil {
+			return fmt.Errorf("dump: %w", err)
+		}
+		return nil
+	}
+	if options.Terminations {
+		if err := goinspect.DumpTerminations(w, c, g); err != nil {
+			return fmt.Errorf("dump: %w", err)
+		}
+		n := 0
+		for _, node := range g.Nodes {
+			if node.Value.Termination != nil && c.NeedNode(node) {
+				n++
 			}
-
-			// detect fullpath from relative path
-			if strings.HasPrefix(c.PkgPath, ".") {
-				if err := func() error {
-					cwd, err := os.Getwd()
-					if err != nil {
-						return fmt.Errorf("getwd: %w", err)
-					}
-					pkgpath, err := fullPkgPath(c.PkgPath, cwd, modInfo, pkgs, options.Debug)
-					if err != nil {
-						return err
-					}
-					log.Printf("detect package path, %q -> %q", c.PkgPath, pkgpath)
-					c.PkgPath = pkgpath
-					return nil
-				}(); err != nil {
-					return fmt.Errorf("detect package path is failed: %w", err)
-				}
+		}
+		if n > 0 {
+			return fmt.Errorf("%d functions may terminate", n)
+		}
+		return nil
+	}
+	if len(options.Only) == 0 {
+		if options.Reverse {
+			return fmt.Errorf("--reverse requires --only")
+		}
+		switch options.Format {
+		case "text":
+			if options.Merge {
+				err = goinspect.DumpAll(w, c, g)
+			} else {
+				err = goinspect.DumpPackages(w, c, g)
 			}
+		case "json":
+			err = goinspect.DumpAllJSON(w, c, g)
+		case "html":
+			if options.Merge {
+				err = goinspect.DumpAllHTML(w, c, g)
+			} else {
+				err = goinspect.DumpPackagesHTML(w, c, g)
+			}
+		default:
+			return fmt.Errorf("unexpected format %q, (text, json, html)", options.Format)
+		}
+		if err != nil {
+			return fmt.Errorf("dump: %w", err)
 		}
+		return nil
 	}
 
-	g, err := goinspect.Scan(c, pkgs)
-	if err != nil {
-		return fmt.Errorf("scan: %w", err)
+	if options.Reverse && options.Format != "text" {
+		return fmt.Errorf("unexpected format %q with --reverse, (text)", options.Format)
 	}
 
-	if len(options.Only) == 0 {
-		if err := goinspect.DumpAll(os.Stdout, c, g); err != nil {
-			return fmt.Errorf("dump: %w", err)
+	nodes := findNodes(g, options.Only)
+	switch options.Format {
+	case "text":
+		if options.Reverse {
+			err = goinspect.DumpReverse(w, c, g, nodes)
+		} else {
+			err = goinspect.Dump(w, c, g, nodes)
 		}
-		return nil
+	case "json":
+		err = goinspect.DumpJSON(w, c, g, nodes)
+	case "html":
+		err = goinspect.DumpHTML(w, c, g, nodes)
+	default:
+		return fmt.Errorf("unexpected format %q, (text, json, html)", options.Format)
 	}
+	if err != nil {
+		return fmt.Errorf("dump: %w", err)
+	}
+	return nil
+}
 
+// findNodes returns the nodes of the symbols (e.g. F, W.M).
+func findNodes(g *goinspect.Graph, only []string) []*goinspect.Node {
 	var nodes []*goinspect.Node
 	g.Walk(func(n *goinspect.Node) {
 		if n.Value.Recv == "" {
-			for _, fullname := range options.Only {
+			for _, fullname := range only {
 				if fullname == n.Name {
 					nodes = append(nodes, n)
 					break
 				}
 			}
 		} else {
-			for _, fullname := range options.Only {
+			for _, fullname := range only {
 				if fullname == n.Value.Recv+"."+n.Name {
 					nodes = append(nodes, n)
 					break
@@ -134,10 +285,191 @@ func run(options Options) error {
 			}
 		}
 	})
-	if err := goinspect.Dump(os.Stdout, c, g, nodes); err != nil {
-		return fmt.Errorf("dump: %w", err)
+	return nodes
+}
+
+// scan loads the packages and scans them, with options.
+func scan(options Options) (*goinspect.Config, *goinspect.Graph, error) {
+	return scanWithCache(options, nil)
+}
+
+// scanWithCache is scan, reusing the files parsed by the previous scans if cache is not nil (--watch).
+func scanWithCache(options Options, cache *parseCache) (*goinspect.Config, *goinspect.Graph, error) {
+	fset := token.NewFileSet()
+	if cache != nil {
+		fset = cache.fset
 	}
-	return nil
+	c := &goinspect.Config{
+		Fset:          fset,
+		OtherPackages: options.Other,
+
+		Padding:           options.Padding,
+		IncludeUnexported: options.IncludeUnexported,
+		IncludeStruct:     !options.OmitStruct,
+		IncludeReferences: options.IncludeReferences,
+		IncludeInit:       options.IncludeInit,
+		IncludeTests:      options.Tests,
+		ExpandAll:         options.ExpandAll,
+		KeepGoing:         options.KeepGoing,
+		TrackErrors:       options.Errors,
+		TrackTerminations: options.Terminations || len(options.Sinks) > 0,
+		TrackLocks:        options.Locks,
+		TrackContexts:     options.Context,
+		TrackComm:         options.Comm,
+		TrackEntryPoints:  options.Entries || len(options.Registrar) > 0,
+		Debug:             options.Debug,
+
+		ShowExternal:            options.ShowExternal || len(options.External) > 0,
+		ExternalPackages:        options.External,
+		ExcludeExternalPackages: options.ExcludeExternal,
+
+		UncoveredOnly: options.Uncovered,
+		MinCum:        options.MinCum,
+		SortByCost:    options.SortCost,
+		EntriesOnly:   options.Entries,
+		ShowMetrics:   options.Metrics,
+	}
+	for _, x := range options.Registrar {
+		r, err := goinspect.ParseRegistrar(x)
+		if err != nil {
+			return nil, nil, fmt.Errorf("--registrar: %w", err)
+		}
+		c.Registrars = append(c.Registrars, r)
+	}
+	if effects, err := goinspect.ParseEffectCategories(options.Effect); err != nil {
+		return nil, nil, fmt.Errorf("--effect: %w", err)
+	} else {
+		c.Effects = effects
+	}
+	if options.Pure && len(c.Effects) == 0 {
+		return nil, nil, fmt.Errorf("--pure requires --effect")
+	}
+	if options.Uncovered && options.Cover == "" {
+		return nil, nil, fmt.Errorf("--uncovered requires --cover")
+	}
+	if (options.MinCum > 0 || options.SortCost || options.ProfileMissing) && options.Profile == "" {
+		return nil, nil, fmt.Errorf("--min-cum, --sort-cost and --profile-missing require --profile")
+	}
+
+	base := baseBuild(options)
+
+	// detect the target package paths from patterns (e.g. ./...)
+	{
+		cfg := &packages.Config{Mode: packages.NeedName}
+		base.Apply(cfg)
+		targets, err := packages.Load(cfg, options.Pkg...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("load target packages: %w", err)
+		}
+		if len(targets) == 0 {
+			return nil, nil, fmt.Errorf("pkg is not found, %q", options.Pkg)
+		}
+		for _, pkg := range targets {
+			c.PkgPaths = append(c.PkgPaths, pkg.PkgPath)
+		}
+		sort.Strings(c.PkgPaths)
+		c.PkgPath = c.PkgPaths[0]
+		if options.Debug {
+			log.Printf("detect package paths, %q -> %q", options.Pkg, c.PkgPaths)
+		}
+	}
+
+	load := func(b goinspect.Build) ([]*packages.Package, error) {
+		cfg := &packages.Config{
+			Fset:  fset,
+			Mode:  packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedImports | packages.NeedTypes | packages.NeedTypesSizes | packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedDeps,
+			Tests: options.Tests,
+		}
+		if cache != nil {
+			cfg.ParseFile = cache.parseFile
+		}
+		b.Apply(cfg)
+		return packages.Load(cfg, append(append([]string{}, options.Pkg...), c.OtherPackages...)...)
+	}
+
+	// handling --short
+	if options.Short {
+		goMod, err := goMod()
+		if err != nil {
+			log.Println("go mod failed, %w", err)
+		}
+		mods, err := modInfo(goMod)
+		if err == nil && len(mods) > 0 {
+			c.TrimPrefix = mods[0].Path
+		}
+	}
+
+	var g *goinspect.Graph
+	var err error
+	if len(options.Build) == 0 {
+		pkgs, loadErr := load(base)
+		if loadErr != nil {
+			return nil, nil, fmt.Errorf("load packages: %w", loadErr)
+		}
+		g, err = goinspect.Scan(c, pkgs)
+	} else {
+		builds := make([]goinspect.Build, len(options.Build))
+		for i, text := range options.Build {
+			b, err := goinspect.ParseBuild(text)
+			if err != nil {
+				return nil, nil, fmt.Errorf("--build: %w", err)
+			}
+			if b.GOOS == "" && b.GOARCH == "" {
+				b.GOOS, b.GOARCH = base.GOOS, base.GOARCH
+			}
+			b.Tags = append(append([]string{}, base.Tags...), b.Tags...)
+			builds[i] = b
+		}
+		g, err = goinspect.ScanBuilds(c, builds, load)
+	}
+	if err != nil {
+		var errs goinspect.PackageErrors
+		if !(options.KeepGoing && errors.As(err, &errs)) {
+			return nil, nil, fmt.Errorf("scan: %w", err)
+		}
+		log.Printf("keep going, %+v", err)
+	}
+
+	if options.Cover != "" {
+		f, err := os.Open(options.Cover)
+		if err != nil {
+			return nil, nil, fmt.Errorf("--cover: %w", err)
+		}
+		defer f.Close()
+		profile, err := goinspect.ParseCoverProfile(f)
+		if err != nil {
+			return nil, nil, fmt.Errorf("--cover: parse %s: %w", options.Cover, err)
+		}
+		goinspect.ApplyCoverage(c, g, profile)
+	}
+	if options.Profile != "" {
+		f, err := os.Open(options.Profile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("--profile: %w", err)
+		}
+		defer f.Close()
+		profile, err := goinspect.LoadProfile(f, options.ProfileSample)
+		if err != nil {
+			return nil, nil, fmt.Errorf("--profile: parse %s: %w", options.Profile, err)
+		}
+		goinspect.ApplyProfile(c, g, profile)
+	}
+	if options.Terminations || len(options.Sinks) > 0 {
+		goinspect.ApplyTerminations(g, options.Sinks)
+	}
+	if len(c.Effects) > 0 {
+		goinspect.ApplyEffects(c, g)
+	}
+	return c, g, nil
+}
+
+// baseBuild returns the build configuration of --goos, --goarch and --tags.
+func baseBuild(options Options) goinspect.Build {
+	base := goinspect.Build{GOOS: options.GOOS, GOARCH: options.GOARCH}
+	if options.Tags != "" {
+		base.Tags = strings.Split(options.Tags, ",")
+	}
+	return base
 }
 
 // from: golang.org/x/tools/cmd/godoc/main.go
@@ -201,71 +533,3 @@ func modInfo(goMod string) ([]mod, error) {
 	}
 	return mods, nil
 }
-
-func fullPkgPath(pkgpath string, cwd string, modInfo mod, pkgs []*packages.Package, debug bool) (string, error) {
-	pkgpath = strings.TrimSuffix(pkgpath, "/")
-
-	var prefix, suffix string
-	parts := strings.Split(pkgpath, "/")
-loop:
-	for i, x := range parts {
-		switch x {
-		case ".":
-			continue
-		case "..":
-			prefix += "../"
-		default:
-			suffix = strings.Join(parts[i:], "/")
-			break loop
-		}
-	}
-
-	// e.g.
-	// modInfo.Dir :: ~/go/github.com/<user>/<name>
-	// modInfo.Path :: /github.com/<user>/<name>
-	// pkgpath :: ../xxx/yyy
-	// cwd :: ~/go/github.com/<user>/<name>/foo/bar
-	// prefix :: ..
-	// suffix :: xxx/yyy
-	if debug {
-		log.Printf("* modInfo: %+v", modInfo)
-		log.Printf("* cwd: %v", cwd)
-		log.Printf("* prefix: %v", prefix)
-		log.Printf("* suffix: %v", suffix)
-	}
-
-	// pkgpath is github.com/<user>/<name>/foo/xxx/yyy
-	abspath, err := filepath.Abs(filepath.Join(cwd, prefix))
-	if err != nil {
-		return "", fmt.Errorf("abspath: %w", err)
-	}
-	rel, err := filepath.Rel(modInfo.Dir, abspath)
-	if err != nil {
-		return "", fmt.Errorf("rel: %w", err)
-	}
-	if debug {
-		log.Printf("* rel: %v", rel)
-	}
-
-	xs := make([]string, 0, 3)
-	for _, x := range []string{modInfo.Path, rel, suffix} {
-		if x == "." || x == "" {
-			continue
-		}
-		xs = append(xs, strings.TrimSuffix(strings.TrimPrefix(x, "/"), "/"))
-	}
-	fullpkgpath := strings.Join(xs, "/")
-
-	if debug {
-		log.Printf("* pkgpath: %v", fullpkgpath)
-	}
-	for _, pkg := range pkgs {
-		if debug {
-			log.Printf("\t* pkg check %5v: pkgpath == %v", pkg.PkgPath == fullpkgpath, pkg.PkgPath)
-		}
-		if pkg.PkgPath == fullpkgpath {
-			return fullpkgpath, nil
-		}
-	}
-	return "", fmt.Errorf("pkg is not found, %q", pkgpath)
-}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/podhmo/goinspect"
+)
+
+func TestDumpReverseFormat(t *testing.T) {
+	for _, format := range []string{"json", "html"} {
+		t.Run(format, func(t *testing.T) {
+			options := Options{Only: []string{"F"}, Reverse: true, Format: format}
+			err := dump(io.Discard, options, &goinspect.Config{}, &goinspect.Graph{})
+			if err == nil || !strings.Contains(err.Error(), "--reverse") {
+				t.Errorf("dump(), unexpected error %v, want the error of --reverse", err)
+			}
+		})
+	}
+}
+
+func TestRunWatchInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		t.Run(interval.String(), func(t *testing.T) {
+			options := Options{Watch: true, Interval: interval}
+			err := run(options)
+			if err == nil || !strings.Contains(err.Error(), "--watch") {
+				t.Errorf("run(), unexpected error %v, want the error of --watch", err)
+			}
+		})
+	}
+}
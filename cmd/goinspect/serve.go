@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/podhmo/goinspect"
+)
+
+// runServe serves the call graph as an HTTP API and an explorer UI (goinspect serve).
+func runServe(options Options) error {
+	host, _, err := net.SplitHostPort(options.Addr)
+	if err != nil {
+		return fmt.Errorf("--addr: %w", err)
+	}
+	if ip := net.ParseIP(host); !(host == "localhost" || (ip != nil && ip.IsLoopback())) {
+		return fmt.Errorf("--addr: only loopback address is allowed, %q", options.Addr)
+	}
+
+	c, g, err := scan(options)
+	if err != nil {
+		return err
+	}
+
+	ln, err := net.Listen("tcp", options.Addr)
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	log.Printf("listening on http://%s", ln.Addr())
+	return http.Serve(ln, goinspect.NewServer(c, g))
+}
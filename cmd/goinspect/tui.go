@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/podhmo/goinspect"
+)
+
+// runTUI browses the call tree interactively (goinspect tui).
+func runTUI(options Options) error {
+	c, g, err := scan(options)
+	if err != nil {
+		return err
+	}
+
+	var nodes []*goinspect.Node
+	if len(options.Only) > 0 {
+		nodes = findNodes(g, options.Only)
+	}
+	t := goinspect.NewTUI(c, g, nodes)
+	if height, width, err := termSize(); err == nil && height > 0 {
+		t.Height, t.Width = height, width
+	}
+
+	saved, err := stty("-g")
+	if err != nil {
+		return fmt.Errorf("tui requires a terminal: %w", err)
+	}
+	restore := func() { stty(saved) }
+	raw := func() { stty("raw", "-echo") }
+	raw()
+	defer restore()
+
+	t.Open = func(filename string, line int) error {
+		editor := os.Getenv("EDITOR")
+		if editor == "" {
+			editor = "vi"
+		}
+		restore()
+		defer raw()
+
+		// $EDITOR can include arguments (e.g. "code --wait")
+		args := append(strings.Fields(editor), "+"+strconv.Itoa(line), filename)
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		return cmd.Run()
+	}
+	return t.Run(os.Stdin, os.Stdout)
+}
+
+// stty runs stty with the current terminal, and returns its output.
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// termSize returns the number of lines and columns of the current terminal.
+func termSize() (int, int, error) {
+	out, err := stty("size")
+	if err != nil {
+		return 0, 0, err
+	}
+	var height, width int
+	if _, err := fmt.Sscan(out, &height, &width); err != nil {
+		return 0, 0, fmt.Errorf("parse stty size %q: %w", out, err)
+	}
+	return height, width, nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/podhmo/goinspect"
+	"golang.org/x/tools/go/packages"
+)
+
+// watch polls the files of the packages, and dumps again on changes (--watch).
+func watch(options Options, c *goinspect.Config, g *goinspect.Graph) error {
+	if err := dump(os.Stdout, options, c, g); err != nil {
+		return err
+	}
+
+	dirs, err := packageDirs(options)
+	if err != nil {
+		return err
+	}
+	prev := takeSnapshot(dirs)
+	cache := newParseCache()
+	for {
+		time.Sleep(options.Interval)
+		current := takeSnapshot(dirs)
+		changed := prev.changed(current)
+		if len(changed) == 0 {
+			continue
+		}
+		prev = current
+
+		fmt.Printf("\n# %s changed: %s\n", time.Now().Format("15:04:05"), strings.Join(changed, ", "))
+		newC, newG, err := scanWithCache(options, cache) // go list and the type checking run again, but the unchanged files are not parsed again
+		if err != nil {
+			log.Printf("scan failed, keep watching: %+v", err)
+			continue
+		}
+		if err := dump(os.Stdout, options, newC, newG); err != nil {
+			return err
+		}
+		if options.Diff {
+			fmt.Println("\n# diff")
+			if err := goinspect.DumpDiff(os.Stdout, newC, g, newG); err != nil {
+				return fmt.Errorf("diff: %w", err)
+			}
+		}
+		c, g = newC, newG
+		if newDirs, err := packageDirs(options); err == nil {
+			dirs = newDirs // e.g. new packages in ./...
+		}
+	}
+}
+
+// parseCache keeps the parsed files between the scans of --watch, the files of the dependencies are parsed only once.
+// The files are shared with the previous graphs, so the file set is shared too.
+type parseCache struct {
+	fset *token.FileSet
+
+	mu    sync.Mutex // ParseFile is called concurrently by packages.Load
+	files map[string]*parsedFile
+}
+
+type parsedFile struct {
+	src  []byte
+	file *ast.File
+	err  error
+}
+
+func newParseCache() *parseCache {
+	return &parseCache{fset: token.NewFileSet(), files: map[string]*parsedFile{}}
+}
+
+// parseFile is packages.Config.ParseFile, the file is parsed again only if the source is changed.
+func (c *parseCache) parseFile(fset *token.FileSet, filename string, src []byte) (*ast.File, error) {
+	c.mu.Lock()
+	cached, ok := c.files[filename]
+	c.mu.Unlock()
+	if ok && bytes.Equal(cached.src, src) {
+		return cached.file, cached.err
+	}
+
+	file, err := parser.ParseFile(fset, filename, src, parser.AllErrors|parser.ParseComments) // the same mode as the default of packages.Load
+	c.mu.Lock()
+	c.files[filename] = &parsedFile{src: src, file: file, err: err}
+	c.mu.Unlock()
+	return file, err
+}
+
+// packageDirs returns the directories of the target packages and the other packages.
+func packageDirs(options Options) ([]string, error) {
+	cfg := &packages.Config{Mode: packages.NeedName | packages.NeedFiles}
+	baseBuild(options).Apply(cfg)
+	pkgs, err := packages.Load(cfg, append(append([]string{}, options.Pkg...), options.Other...)...)
+	if err != nil {
+		return nil, fmt.Errorf("load packages: %w", err)
+	}
+
+	seen := map[string]bool{}
+	var dirs []string
+	for _, pkg := range pkgs {
+		for _, filename := range pkg.GoFiles {
+			if dir := filepath.Dir(filename); !seen[dir] {
+				seen[dir] = true
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// snapshot is the modification times of the go files.
+type snapshot map[string]time.Time
+
+func takeSnapshot(dirs []string) snapshot {
+	s := snapshot{}
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue // removed
+		}
+		for _, e := range entries {
+			if e.IsDir() || !strings.HasSuffix(e.Name(), ".go") {
+				continue
+			}
+			if info, err := e.Info(); err == nil {
+				s[filepath.Join(dir, e.Name())] = info.ModTime()
+			}
+		}
+	}
+	return s
+}
+
+// changed returns the files added, removed or modified in the current snapshot.
+func (s snapshot) changed(current snapshot) []string {
+	var files []string
+	for filename, t := range current {
+		if prev, ok := s[filename]; !ok || !prev.Equal(t) {
+			files = append(files, filepath.Base(filename))
+		}
+	}
+	for filename := range s {
+		if _, ok := current[filename]; !ok {
+			files = append(files, filepath.Base(filename))
+		}
+	}
+	sort.Strings(files)
+	return files
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseCache(t *testing.T) {
+	cache := newParseCache()
+	parse := func(src string) interface{} {
+		t.Helper()
+		f, err := cache.parseFile(cache.fset, "x.go", []byte(src))
+		if err != nil {
+			t.Fatalf("unexpected error: %+v", err)
+		}
+		return f
+	}
+
+	first := parse("package x\n")
+	if got := parse("package x\n"); got != first {
+		t.Errorf("the unchanged file is parsed again")
+	}
+	if got := parse("package x\n\nfunc F() {}\n"); got == first {
+		t.Errorf("the changed file is not parsed again")
+	}
+}
@@ -0,0 +1,112 @@
+package goinspect
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"github.com/podhmo/goinspect/graph"
+	"golang.org/x/tools/go/packages"
+)
+
+// CommGraph is the goroutine and channel communication map.
+// The edges are spawner -> goroutine (go f()), sender -> channel (ch <- x), and channel -> receiver (<-ch, range ch).
+type CommGraph = graph.Graph[string, *CommNode]
+
+// CommNode is a function or a channel in the communication map.
+type CommNode struct {
+	Node *Node  // the function, nil if channel
+	Chan string // the channel variable, <pkgpath>.<type>.<field> or <pkgpath>.<name>, if channel
+	Text string
+}
+
+func (n *CommNode) String() string {
+	return n.Text
+}
+
+// chanVar returns the name of the channel-typed struct field or package-level variable (see sharedVar), or "".
+func chanVar(pkg *packages.Package, expr ast.Expr) string {
+	t := pkg.TypesInfo.TypeOf(expr)
+	if t == nil {
+		return ""
+	}
+	if _, ok := t.Underlying().(*types.Chan); !ok {
+		return ""
+	}
+	return sharedVar(pkg, expr)
+}
+
+// inGoroutine reports whether the call (the last of stack) runs in a new goroutine, go f() or the calls in go func() { ... }().
+func inGoroutine(stack []ast.Node) bool {
+	call := stack[len(stack)-1]
+	if len(stack) >= 2 {
+		if stmt, ok := stack[len(stack)-2].(*ast.GoStmt); ok && stmt.Call == call {
+			return true
+		}
+	}
+	for i := len(stack) - 2; i >= 2; i-- {
+		lit, ok := stack[i].(*ast.FuncLit)
+		if !ok {
+			continue
+		}
+		if parent, ok := stack[i-1].(*ast.CallExpr); ok && unparen(parent.Fun) == lit {
+			if _, ok := stack[i-2].(*ast.GoStmt); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CommMap returns the goroutine and channel communication map of the graph (Subject.Spawns, Subject.Sends, Subject.Recvs).
+// The functions run as goroutines are shown as "go <function>", and the channels are shown as "chan <variable>" (rhombus in mermaid).
+// The sends and receives in go func() { ... }() are treated as the ones of the enclosing function.
+func CommMap(c *Config, g *Graph) *CommGraph {
+	prefix := textPrefix(c.PkgPath)
+	cg := graph.New(func(n *CommNode) string {
+		if n.Node != nil {
+			return n.Node.Value.ID
+		}
+		return "chan " + n.Chan
+	})
+
+	byID := make(map[string]*Node, len(g.Nodes))
+	spawned := map[string]bool{}
+	for _, n := range g.Nodes {
+		byID[n.Value.ID] = n
+		for _, id := range n.Value.Spawns {
+			spawned[id] = true
+		}
+	}
+	fn := func(n *Node) *graph.Node[*CommNode] {
+		text := nodeText(c, n, prefix)
+		if spawned[n.Value.ID] {
+			text = "go " + text
+		}
+		return cg.Madd(&CommNode{Node: n, Text: text})
+	}
+	ch := func(name string) *graph.Node[*CommNode] {
+		x, added := cg.Add(&CommNode{Chan: name, Text: "chan " + strings.ReplaceAll(name, prefix, "")})
+		if added {
+			x.Metadata.Shape = graph.ShapeRhombus
+		}
+		return x
+	}
+
+	for _, n := range g.Nodes {
+		if len(n.Value.Spawns) == 0 && len(n.Value.Sends) == 0 && len(n.Value.Recvs) == 0 {
+			continue
+		}
+		x := fn(n)
+		for _, id := range n.Value.Spawns {
+			cg.LinkTo(x, fn(byID[id]))
+		}
+		for _, name := range n.Value.Sends {
+			cg.LinkTo(x, ch(name))
+		}
+		for _, name := range n.Value.Recvs {
+			cg.LinkTo(ch(name), x)
+		}
+	}
+	return cg
+}
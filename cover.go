@@ -0,0 +1,141 @@
+package goinspect
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// CoverProfile is the coverage profile of go test -coverprofile.
+type CoverProfile struct {
+	Mode   string                  // set, count or atomic
+	Blocks map[string][]CoverBlock // <pkgpath>/<basename> -> blocks
+}
+
+// CoverBlock is a block of the coverage profile.
+type CoverBlock struct {
+	StartLine, StartCol int
+	EndLine, EndCol     int
+	NumStmt             int
+	Count               int
+}
+
+// Cover is the statement coverage of a function.
+type Cover struct {
+	Covered int // the number of covered statements
+	Total   int
+}
+
+// Percent returns the percentage of the covered statements.
+func (c *Cover) Percent() float64 {
+	if c.Total == 0 {
+		return 0
+	}
+	return 100 * float64(c.Covered) / float64(c.Total)
+}
+
+// ParseCoverProfile parses the coverage profile ("mode: <mode>", and "<file>:<line>.<col>,<line>.<col> <stmts> <count>" lines).
+func ParseCoverProfile(r io.Reader) (*CoverProfile, error) {
+	p := &CoverProfile{Blocks: map[string][]CoverBlock{}}
+	seen := map[string]int{} // the index of the same block, merged (e.g. the profiles of multiple packages concatenated)
+	scanner := bufio.NewScanner(r)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if mode := strings.TrimPrefix(line, "mode: "); mode != line {
+			p.Mode = mode
+			continue
+		}
+
+		// <file>:<line>.<col>,<line>.<col> <stmts> <count>
+		i := strings.LastIndex(line, ":")
+		fields := strings.Fields(line[i+1:])
+		if i < 0 || len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: unexpected format %q", lineno, line)
+		}
+		filename, pos := line[:i], fields[0]
+		var b CoverBlock
+		if _, err := fmt.Sscanf(pos, "%d.%d,%d.%d", &b.StartLine, &b.StartCol, &b.EndLine, &b.EndCol); err != nil {
+			return nil, fmt.Errorf("line %d: unexpected position %q", lineno, pos)
+		}
+		var err error
+		if b.NumStmt, err = strconv.Atoi(fields[1]); err != nil {
+			return nil, fmt.Errorf("line %d: unexpected number of statements %q", lineno, fields[1])
+		}
+		if b.Count, err = strconv.Atoi(fields[2]); err != nil {
+			return nil, fmt.Errorf("line %d: unexpected count %q", lineno, fields[2])
+		}
+
+		key := filename + ":" + pos
+		if j, ok := seen[key]; ok {
+			p.Blocks[filename][j].Count += b.Count
+			continue
+		}
+		seen[key] = len(p.Blocks[filename])
+		p.Blocks[filename] = append(p.Blocks[filename], b)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if p.Mode == "" {
+		return nil, fmt.Errorf("mode line is not found")
+	}
+	return p, nil
+}
+
+// ApplyCoverage sets the coverage of the functions and methods in the profile (Subject.Cover).
+func ApplyCoverage(c *Config, g *Graph, p *CoverProfile) {
+	for _, n := range g.Nodes {
+		decl, ok := n.Value.Decl.(*ast.FuncDecl)
+		if !ok || n.Value.Object.Pkg() == nil {
+			continue
+		}
+		start, end := c.Fset.Position(decl.Pos()), c.Fset.Position(decl.End())
+		blocks, ok := p.Blocks[n.Value.Object.Pkg().Path()+"/"+filepath.Base(start.Filename)]
+		if !ok {
+			continue
+		}
+
+		cover := &Cover{}
+		for _, b := range blocks {
+			if (b.StartLine < start.Line || (b.StartLine == start.Line && b.StartCol < start.Column)) ||
+				(b.EndLine > end.Line || (b.EndLine == end.Line && b.EndCol > end.Column)) {
+				continue
+			}
+			cover.Total += b.NumStmt
+			if b.Count > 0 {
+				cover.Covered += b.NumStmt
+			}
+		}
+		if cover.Total > 0 {
+			n.Value.Cover = cover
+		}
+	}
+}
+
+// uncoveredPaths returns the IDs of the nodes reaching the uncovered functions (0%), including themselves.
+func uncoveredPaths(g *Graph) map[int]struct{} {
+	r := map[int]struct{}{}
+	var q []*Node
+	for _, n := range g.Nodes {
+		if n.Value.Cover != nil && n.Value.Cover.Covered == 0 {
+			q = append(q, n)
+		}
+	}
+	for len(q) > 0 {
+		var n *Node
+		n, q = q[0], q[1:]
+		if _, ok := r[n.ID]; ok {
+			continue
+		}
+		r[n.ID] = struct{}{}
+		q = append(q, n.From...)
+	}
+	return r
+}
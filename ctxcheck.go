@@ -0,0 +1,158 @@
+package goinspect
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"io"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// ContextFinding is a finding of the context.Context propagation.
+type ContextFinding struct {
+	Kind string  // "replaced" (a new context is passed, instead of the received one), or "orphaned" (the callers have no context)
+	Path []*Node // the call path, the caller and the callee (replaced), or from a root to the function (orphaned)
+	Expr string  // the new context passed, e.g. context.Background() (replaced)
+}
+
+// contextParam returns the index of the context.Context parameter of the function, or -1.
+func contextParam(ob types.Object) int {
+	if ob == nil {
+		return -1
+	}
+	sig, ok := ob.Type().(*types.Signature)
+	if !ok {
+		return -1
+	}
+	for i := 0; i < sig.Params().Len(); i++ {
+		if isContextType(sig.Params().At(i).Type()) {
+			return i
+		}
+	}
+	return -1
+}
+
+func isContextType(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "context" && named.Obj().Name() == "Context"
+}
+
+// newContextArg returns the text of the new context passed to the context.Context parameter of child (context.Background() or context.TODO()), or "".
+func newContextArg(pkg *packages.Package, call *ast.CallExpr, child *Node) string {
+	i := contextParam(child.Value.Object)
+	if i < 0 || i >= len(call.Args) {
+		return ""
+	}
+	arg, ok := unparen(call.Args[i]).(*ast.CallExpr)
+	if !ok {
+		return ""
+	}
+	var fn *types.Func
+	switch sym := unparen(arg.Fun).(type) {
+	case *ast.SelectorExpr:
+		fn, _ = pkg.TypesInfo.Uses[sym.Sel].(*types.Func)
+	case *ast.Ident:
+		fn, _ = pkg.TypesInfo.Uses[sym].(*types.Func) // dot-imported
+	}
+	if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != "context" || (fn.Name() != "Background" && fn.Name() != "TODO") {
+		return ""
+	}
+	return "context." + fn.Name() + "()"
+}
+
+// ContextFindings returns the calls passing a new context (context.Background() or context.TODO()) from the functions receiving a context,
+// and the functions receiving a context, only called from the functions without context.
+func ContextFindings(g *Graph) []ContextFinding {
+	var findings []ContextFinding
+	for _, n := range g.Nodes {
+		if contextParam(n.Value.Object) < 0 {
+			continue
+		}
+		for _, next := range n.To {
+			if expr, ok := n.Value.NewContexts[next.Value.ID]; ok {
+				findings = append(findings, ContextFinding{Kind: "replaced", Path: []*Node{n, next}, Expr: expr})
+			}
+		}
+	}
+
+	for _, n := range g.Nodes {
+		if contextParam(n.Value.Object) < 0 {
+			continue
+		}
+		callers := 0
+		orphaned := true
+		for _, prev := range n.From {
+			if prev.Value.Kind == KindObject || prev.Value.Refs[n.Value.ID] {
+				continue
+			}
+			callers++
+			if contextParam(prev.Value.Object) >= 0 {
+				orphaned = false
+				break
+			}
+		}
+		if callers > 0 && orphaned {
+			findings = append(findings, ContextFinding{Kind: "orphaned", Path: pathFromRoot(n)})
+		}
+	}
+	return findings
+}
+
+// pathFromRoot returns the shortest call path from a root (a function without callers) to n.
+func pathFromRoot(n *Node) []*Node {
+	next := map[int]*Node{n.ID: nil}
+	q := []*Node{n}
+	for len(q) > 0 {
+		var x *Node
+		x, q = q[0], q[1:]
+		callers := 0
+		for _, prev := range x.From {
+			if prev.Value.Kind == KindObject || prev.Value.Refs[x.Value.ID] {
+				continue
+			}
+			callers++
+			if _, ok := next[prev.ID]; !ok {
+				next[prev.ID] = x
+				q = append(q, prev)
+			}
+		}
+		if callers == 0 {
+			path := []*Node{x}
+			for y := next[x.ID]; y != nil; y = next[y.ID] {
+				path = append(path, y)
+			}
+			return path
+		}
+	}
+	return []*Node{n} // only in cycles
+}
+
+// DumpContextFindings dumps the findings of the context.Context propagation.
+//
+//	replaced: <caller> -> <callee>  context.Background()
+//	orphaned: <root> -> ... -> <function>
+func DumpContextFindings(w io.Writer, c *Config, findings []ContextFinding) error {
+	prefix := textPrefix(c.PkgPath)
+	lines := make([]string, 0, len(findings))
+	for _, f := range findings {
+		texts := make([]string, len(f.Path))
+		for i, x := range f.Path {
+			texts[i] = nodeText(c, x, prefix)
+		}
+		line := f.Kind + ": " + strings.Join(texts, " -> ")
+		if f.Expr != "" {
+			line += "  " + f.Expr
+		}
+		lines = append(lines, line)
+	}
+	sort.Strings(lines)
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package goinspect
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// DumpDiff dumps the nodes and the edges removed from the previous graph as "- <text>", and the added ones as "+ <text>".
+func DumpDiff(w io.Writer, c *Config, prev *Graph, g *Graph) error {
+	before, after := diffLines(c, prev), diffLines(c, g)
+
+	type line struct {
+		op   string
+		text string
+	}
+	var lines []line
+	for text := range before {
+		if !after[text] {
+			lines = append(lines, line{op: "-", text: text})
+		}
+	}
+	for text := range after {
+		if !before[text] {
+			lines = append(lines, line{op: "+", text: text})
+		}
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].text == lines[j].text {
+			return lines[i].op < lines[j].op
+		}
+		return lines[i].text < lines[j].text
+	})
+
+	for _, l := range lines {
+		if _, err := fmt.Fprintf(w, "%s %s\n", l.op, l.text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffLines returns the texts of the nodes, and the edges ("<text> -> <text>").
+func diffLines(c *Config, g *Graph) map[string]bool {
+	prefix := textPrefix(c.PkgPath)
+	need := c.NeedNode
+
+	lines := map[string]bool{}
+	g.Walk(func(n *Node) {
+		if !need(n) {
+			return
+		}
+		text := nodeText(c, n, prefix)
+		lines[text] = true
+		for _, next := range n.To {
+			if !need(next) {
+				continue
+			}
+			edge := text + " -> " + nodeText(c, next, prefix)
+			if n.Value.Refs[next.Value.ID] {
+				edge = text + " -> ref " + nodeText(c, next, prefix)
+			}
+			lines[edge] = true
+		}
+	})
+	return lines
+}
@@ -0,0 +1,169 @@
+package goinspect
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// EffectCategory is a category of side effects (e.g. io, db), matched by the called functions.
+type EffectCategory struct {
+	Name     string
+	Patterns []string // globs of the called functions, <pkgpath>.<func> or <pkgpath>.<type>.<method> (e.g. os.*, database/sql.*, net/http.Client.*, time.Now)
+}
+
+// Effect is the witness that a function has the side effect of a category.
+type Effect struct {
+	Call string  // the called function matched by the category (e.g. database/sql.DB.Exec)
+	Path []*Node // the call path to the function calling Call directly, including the function itself
+}
+
+// ParseEffectCategories parses the categories, <name>=<pattern> (e.g. io=os.*, io=io.*). The patterns of the same name are merged, in order.
+func ParseEffectCategories(xs []string) ([]EffectCategory, error) {
+	var categories []EffectCategory
+	index := map[string]int{}
+	for _, x := range xs {
+		name, pattern, ok := strings.Cut(x, "=")
+		name, pattern = strings.TrimSpace(name), strings.TrimSpace(pattern)
+		if !ok || name == "" || pattern == "" {
+			return nil, fmt.Errorf("effect %q, <name>=<pattern> is expected (e.g. io=os.*)", x)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("effect %q: %w", x, err)
+		}
+		i, ok := index[name]
+		if !ok {
+			i = len(categories)
+			index[name] = i
+			categories = append(categories, EffectCategory{Name: name})
+		}
+		categories[i].Patterns = append(categories[i].Patterns, pattern)
+	}
+	return categories, nil
+}
+
+// calledName returns the name of the function or method called by call, <pkgpath>.<func> or <pkgpath>.<type>.<method> (e.g. os.WriteFile, io.Writer.Write), or "".
+func calledName(pkg *packages.Package, call *ast.CallExpr) string {
+	var fn *types.Func
+	switch sym := unparen(call.Fun).(type) {
+	case *ast.Ident:
+		fn, _ = pkg.TypesInfo.Uses[sym].(*types.Func)
+	case *ast.SelectorExpr:
+		fn, _ = pkg.TypesInfo.Uses[sym.Sel].(*types.Func)
+	}
+	if fn == nil || fn.Pkg() == nil {
+		return ""
+	}
+	if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+		named := namedOf(recv.Type())
+		if named == nil {
+			return ""
+		}
+		return fn.Pkg().Path() + "." + named.Obj().Name() + "." + fn.Name()
+	}
+	return fn.Pkg().Path() + "." + fn.Name()
+}
+
+// scanEffects records the categories of the side effects of the call, in the function of node (Subject.EffectCalls).
+func (s *Scanner) scanEffects(pkg *packages.Package, node *Node, call *ast.CallExpr) {
+	if len(s.Config.Effects) == 0 {
+		return
+	}
+	name := calledName(pkg, call)
+	if name == "" {
+		return
+	}
+	for _, category := range s.Config.Effects {
+		if _, ok := node.Value.EffectCalls[category.Name]; ok {
+			continue
+		}
+		for _, pattern := range category.Patterns {
+			if ok, _ := path.Match(pattern, name); ok {
+				if node.Value.EffectCalls == nil {
+					node.Value.EffectCalls = map[string]string{}
+				}
+				node.Value.EffectCalls[category.Name] = name
+				break
+			}
+		}
+	}
+}
+
+// ApplyEffects sets the side effects of the functions, reached directly or transitively (Subject.Effects).
+// The witness is the shortest call path to the function calling the matched function, the edges of the references (not called) are not followed.
+func ApplyEffects(c *Config, g *Graph) {
+	for _, n := range g.Nodes {
+		n.Value.Effects = nil
+	}
+	for _, category := range c.Effects {
+		name := category.Name
+		var q []*Node
+		for _, n := range g.Nodes {
+			if call, ok := n.Value.EffectCalls[name]; ok {
+				setEffect(n, name, &Effect{Call: call, Path: []*Node{n}})
+				q = append(q, n)
+			}
+		}
+		for len(q) > 0 {
+			var n *Node
+			n, q = q[0], q[1:]
+			effect := n.Value.Effects[name]
+			for _, prev := range n.From {
+				if _, ok := prev.Value.Effects[name]; ok || prev.Value.Kind == KindObject || prev.Value.Refs[n.Value.ID] {
+					continue
+				}
+				setEffect(prev, name, &Effect{Call: effect.Call, Path: append([]*Node{prev}, effect.Path...)})
+				q = append(q, prev)
+			}
+		}
+	}
+}
+
+func setEffect(n *Node, category string, effect *Effect) {
+	if n.Value.Effects == nil {
+		n.Value.Effects = map[string]*Effect{}
+	}
+	n.Value.Effects[category] = effect
+}
+
+// effectNames returns the sorted categories of the side effects of the node.
+func effectNames(n *Node) []string {
+	if len(n.Value.Effects) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(n.Value.Effects))
+	for name := range n.Value.Effects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DumpPure dumps the functions and methods without side effects of the categories, after ApplyEffects.
+// The calls through interfaces and function values are not followed, so they are not guaranteed to be pure.
+func DumpPure(w io.Writer, c *Config, g *Graph) error {
+	prefix := textPrefix(c.PkgPath)
+	var lines []string
+	for _, n := range g.Nodes {
+		if _, ok := n.Value.Decl.(*ast.FuncDecl); !ok || len(n.Value.Effects) > 0 {
+			continue
+		}
+		if !c.NeedNode(n) {
+			continue
+		}
+		lines = append(lines, nodeText(c, n, prefix))
+	}
+	sort.Strings(lines)
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
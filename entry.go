@@ -0,0 +1,167 @@
+package goinspect
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Registrar is the function, method or struct field registering entry points (e.g. HTTP handlers, CLI commands).
+// The functions and methods passed to it are treated as roots (Subject.EntryPoints).
+type Registrar struct {
+	Name string // <pkgpath>.<func> (e.g. net/http.HandleFunc), <pkgpath>.<type>#<method> (e.g. net/http.ServeMux#Handle), or <pkgpath>.<type>.<field> (e.g. github.com/spf13/cobra.Command.RunE)
+	Arg  int    // the index of the registered argument, -1 if the field
+}
+
+func (r Registrar) String() string {
+	if r.Arg < 0 {
+		return r.Name
+	}
+	return r.Name + ":" + strconv.Itoa(r.Arg)
+}
+
+// DefaultRegistrars is the built-in registrars of net/http.
+var DefaultRegistrars = []Registrar{
+	{Name: "net/http.HandleFunc", Arg: 1},
+	{Name: "net/http.Handle", Arg: 1},
+	{Name: "net/http.ServeMux#HandleFunc", Arg: 1},
+	{Name: "net/http.ServeMux#Handle", Arg: 1},
+	{Name: "net/http.Server.Handler", Arg: -1},
+}
+
+// ParseRegistrar parses the registrar, <func>:<arg> or <type>#<method>:<arg> (e.g. net/http.HandleFunc:1), or <type>.<field> (e.g. github.com/spf13/cobra.Command.RunE).
+func ParseRegistrar(s string) (Registrar, error) {
+	name, arg, ok := strings.Cut(s, ":")
+	if !ok {
+		if strings.Contains(s, "#") {
+			return Registrar{}, fmt.Errorf("registrar %q, the argument index is required for the method (e.g. %s:1)", s, s)
+		}
+		return Registrar{Name: s, Arg: -1}, nil
+	}
+	i, err := strconv.Atoi(arg)
+	if err != nil || i < 0 {
+		return Registrar{}, fmt.Errorf("registrar %q, unexpected argument index %q", s, arg)
+	}
+	return Registrar{Name: name, Arg: i}, nil
+}
+
+// registrar returns the registrar of name, with Config.Registrars and DefaultRegistrars.
+func (s *Scanner) registrar(name string) (Registrar, bool) {
+	if s.registrars == nil {
+		s.registrars = map[string]Registrar{}
+		for _, r := range DefaultRegistrars {
+			s.registrars[r.Name] = r
+		}
+		for _, r := range s.Config.Registrars {
+			s.registrars[r.Name] = r
+		}
+	}
+	r, ok := s.registrars[name]
+	return r, ok
+}
+
+// scanRegistration marks the functions and methods passed to the registrar called by call as entry points (e.g. http.HandleFunc("/", index)).
+func (s *Scanner) scanRegistration(pkg *packages.Package, call *ast.CallExpr) {
+	var fn *types.Func
+	switch sym := unparen(call.Fun).(type) {
+	case *ast.Ident:
+		fn, _ = pkg.TypesInfo.Uses[sym].(*types.Func)
+	case *ast.SelectorExpr:
+		fn, _ = pkg.TypesInfo.Uses[sym.Sel].(*types.Func)
+	}
+	if fn == nil || fn.Pkg() == nil {
+		return
+	}
+	sig := fn.Type().(*types.Signature)
+	name := fn.Pkg().Path() + "." + fn.Name()
+	if recv := sig.Recv(); recv != nil {
+		named := namedOf(recv.Type())
+		if named == nil {
+			return // interface methods
+		}
+		name = fn.Pkg().Path() + "." + named.Obj().Name() + "#" + fn.Name()
+	}
+	r, ok := s.registrar(name)
+	if !ok || r.Arg < 0 || r.Arg >= len(call.Args) || r.Arg >= sig.Params().Len() {
+		return
+	}
+	s.markEntryPoints(pkg, r, call.Args[r.Arg], sig.Params().At(r.Arg).Type())
+}
+
+// scanRegistrationField marks the functions and methods set to the registrar field as entry points (e.g. cobra.Command{RunE: run}, cmd.RunE = run).
+func (s *Scanner) scanRegistrationField(pkg *packages.Package, owner types.Type, field string, value ast.Expr) {
+	named := namedOf(owner)
+	if named == nil {
+		return
+	}
+	r, ok := s.registrar(objectPath(named.Obj()) + "." + field)
+	if !ok || r.Arg >= 0 {
+		return
+	}
+	st, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		if f := st.Field(i); f.Name() == field {
+			s.markEntryPoints(pkg, r, value, f.Type())
+			return
+		}
+	}
+}
+
+// markEntryPoints marks the functions and methods of expr as entry points of the registrar.
+// If expr is not a function value, the methods of the interface (typ) implemented by it are marked (e.g. ServeHTTP of http.Handler).
+func (s *Scanner) markEntryPoints(pkg *packages.Package, r Registrar, expr ast.Expr, typ types.Type) {
+	expr = unparen(expr)
+	if call, ok := expr.(*ast.CallExpr); ok && len(call.Args) == 1 {
+		if tv, ok := pkg.TypesInfo.Types[call.Fun]; ok && tv.IsType() {
+			expr = unparen(call.Args[0]) // the conversion, e.g. http.HandlerFunc(f)
+		}
+	}
+	if n := s.funcValue(pkg, expr); n != nil {
+		n.Value.EntryPoints = appendUnique(n.Value.EntryPoints, r.Name)
+		return
+	}
+
+	iface, ok := typ.Underlying().(*types.Interface)
+	if !ok {
+		return
+	}
+	t := pkg.TypesInfo.TypeOf(expr)
+	if t == nil {
+		return
+	}
+	named := namedOf(t)
+	if named == nil {
+		return
+	}
+	for i := 0; i < iface.NumMethods(); i++ {
+		ob, _, _ := types.LookupFieldOrMethod(t, true, named.Obj().Pkg(), iface.Method(i).Name())
+		if fn, ok := ob.(*types.Func); ok && fn.Pkg() != nil && s.needPkg(fn.Pkg().Path(), fn) {
+			n := s.methodNode(fn, named)
+			n.Value.EntryPoints = appendUnique(n.Value.EntryPoints, r.Name)
+		}
+	}
+}
+
+// isEntryPoint reports whether the node is registered as an entry point, it is shown even if unexported (Config.NeedNode).
+func isEntryPoint(n *Node) bool {
+	return len(n.Value.EntryPoints) > 0
+}
+
+// entryTexts returns the registrars of the entry point, without prefix (e.g. net/http.HandleFunc, x.Command.RunE).
+func entryTexts(n *Node, prefix string) []string {
+	if len(n.Value.EntryPoints) == 0 {
+		return nil
+	}
+	texts := make([]string, len(n.Value.EntryPoints))
+	for i, name := range n.Value.EntryPoints {
+		texts[i] = strings.ReplaceAll(name, prefix, "")
+	}
+	return texts
+}
@@ -0,0 +1,164 @@
+package goinspect
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// ErrorHandling is how the error returned by a call is handled by the caller.
+type ErrorHandling string
+
+const (
+	ErrorPropagated ErrorHandling = "propagated" // returned as is (e.g. return err, return f())
+	ErrorWrapped    ErrorHandling = "wrapped"    // returned in another value (e.g. return fmt.Errorf("...: %w", err))
+	ErrorHandled    ErrorHandling = "handled"    // used, but not returned (e.g. checked, logged)
+	ErrorDiscarded  ErrorHandling = "discarded"  // assigned to _
+	ErrorIgnored    ErrorHandling = "ignored"    // the result is not used (e.g. f(), go f(), defer f())
+)
+
+var errorType = types.Universe.Lookup("error").Type()
+
+// returnsError reports whether the last result of the function is error.
+func returnsError(n *Node) bool {
+	if n.Value.Object == nil {
+		return false
+	}
+	sig, ok := n.Value.Object.Type().(*types.Signature)
+	if !ok || sig.Results().Len() == 0 {
+		return false
+	}
+	return types.Identical(sig.Results().At(sig.Results().Len()-1).Type(), errorType)
+}
+
+// errorHandling returns how the error of the call (the last of stack) is handled in the function of node. stack is the ancestors of the call in the body.
+func errorHandling(pkg *packages.Package, node *Node, stack []ast.Node) ErrorHandling {
+	var call ast.Node = stack[len(stack)-1]
+	nested := false // the call is a part of another expression (e.g. an argument)
+	for i := len(stack) - 2; i >= 0; i-- {
+		switch parent := stack[i].(type) {
+		case *ast.ParenExpr:
+			continue
+		case *ast.ExprStmt, *ast.GoStmt, *ast.DeferStmt:
+			if nested {
+				return ErrorHandled
+			}
+			return ErrorIgnored
+		case *ast.ReturnStmt:
+			if nested {
+				return ErrorWrapped
+			}
+			return ErrorPropagated
+		case *ast.AssignStmt:
+			if nested {
+				return ErrorHandled
+			}
+			return assignedErrorHandling(pkg, node, stack[:i+1], parent.Lhs, parent.Rhs, call)
+		case *ast.ValueSpec:
+			if nested {
+				return ErrorHandled
+			}
+			lhs := make([]ast.Expr, len(parent.Names))
+			for j, name := range parent.Names {
+				lhs[j] = name
+			}
+			return assignedErrorHandling(pkg, node, stack[:i+1], lhs, parent.Values, call)
+		case ast.Expr:
+			nested = true
+			call = parent
+		default:
+			return ErrorHandled // e.g. if f() != nil {...}, ch <- f()
+		}
+	}
+	return ErrorHandled
+}
+
+// assignedErrorHandling returns how the error assigned to a variable is handled (e.g. x, err := f()).
+func assignedErrorHandling(pkg *packages.Package, node *Node, stack []ast.Node, lhs []ast.Expr, rhs []ast.Expr, call ast.Node) ErrorHandling {
+	var target ast.Expr
+	if len(rhs) == 1 && len(lhs) > 1 {
+		target = lhs[len(lhs)-1] // x, err := f()
+	} else {
+		for i, x := range rhs {
+			if unparen(x) == call && i < len(lhs) {
+				target = lhs[i]
+			}
+		}
+	}
+	ident, ok := target.(*ast.Ident)
+	if !ok {
+		return ErrorHandled // e.g. s.err = f()
+	}
+	if ident.Name == "_" {
+		return ErrorDiscarded
+	}
+	ob := pkg.TypesInfo.ObjectOf(ident)
+	if ob == nil {
+		return ErrorHandled
+	}
+
+	// the enclosing function
+	var body ast.Node = stack[0]
+	var typ *ast.FuncType
+	if decl, ok := node.Value.Decl.(*ast.FuncDecl); ok {
+		typ = decl.Type
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		if lit, ok := stack[i].(*ast.FuncLit); ok {
+			body, typ = lit.Body, lit.Type
+			break
+		}
+	}
+
+	if typ != nil && typ.Results != nil {
+		for _, field := range typ.Results.List {
+			for _, name := range field.Names {
+				if pkg.TypesInfo.Defs[name] == ob {
+					return ErrorPropagated // the named result
+				}
+			}
+		}
+	}
+
+	r := ErrorHandled
+	ast.Inspect(body, func(t ast.Node) bool {
+		switch t := t.(type) {
+		case *ast.FuncLit:
+			return false // the returns of the other function
+		case *ast.ReturnStmt:
+			for _, result := range t.Results {
+				if x, ok := unparen(result).(*ast.Ident); ok && pkg.TypesInfo.Uses[x] == ob {
+					r = ErrorPropagated
+					return false
+				}
+				if r == ErrorHandled && usesObject(pkg, result, ob) {
+					r = ErrorWrapped
+				}
+			}
+		}
+		return r != ErrorPropagated
+	})
+	return r
+}
+
+func unparen(expr ast.Expr) ast.Expr {
+	for {
+		p, ok := expr.(*ast.ParenExpr)
+		if !ok {
+			return expr
+		}
+		expr = p.X
+	}
+}
+
+func usesObject(pkg *packages.Package, expr ast.Expr, ob types.Object) bool {
+	found := false
+	ast.Inspect(expr, func(t ast.Node) bool {
+		if x, ok := t.(*ast.Ident); ok && pkg.TypesInfo.Uses[x] == ob {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
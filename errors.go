@@ -0,0 +1,61 @@
+package goinspect
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// PackageErrors is the errors of the loaded packages (list, parse and type errors), with positions.
+type PackageErrors []packages.Error
+
+func (errs PackageErrors) Error() string {
+	lines := make([]string, len(errs))
+	for i, err := range errs {
+		lines[i] = err.Error()
+	}
+	return fmt.Sprintf("%d errors in loaded packages:\n\t%s", len(errs), strings.Join(lines, "\n\t"))
+}
+
+// collectErrors collects the errors of all loaded packages, including dependencies.
+func collectErrors(pkgs []*packages.Package) PackageErrors {
+	var errs PackageErrors
+	seen := map[string]bool{}
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, err := range pkg.Errors {
+			k := err.Error()
+			if seen[k] { // the errors of test variants are duplicated
+				continue
+			}
+			seen[k] = true
+			errs = append(errs, err)
+		}
+	})
+	return errs
+}
+
+// errorLines returns the lines of errors for each file (filename -> lines).
+func errorLines(errs PackageErrors) map[string][]int {
+	r := map[string][]int{}
+	for _, err := range errs {
+		// <filename>:<line>:<column> or <filename>:<line>
+		filename, line := err.Pos, 0
+		for i := 0; i < 2; i++ {
+			idx := strings.LastIndex(filename, ":")
+			if idx < 0 {
+				break
+			}
+			n, convErr := strconv.Atoi(filename[idx+1:])
+			if convErr != nil {
+				break
+			}
+			filename, line = filename[:idx], n
+		}
+		if line > 0 {
+			r[filename] = append(r[filename], line)
+		}
+	}
+	return r
+}
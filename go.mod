@@ -3,9 +3,12 @@ module github.com/podhmo/goinspect
 go 1.18
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/google/go-cmp v0.5.8
+	github.com/google/pprof v0.0.0-20221118152302-e6195bd50e26
 	github.com/podhmo/flagstruct v0.5.0
 	golang.org/x/tools v0.1.12
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
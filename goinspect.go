@@ -6,10 +6,10 @@ import (
 	"io"
 	"log"
 	"os"
+	"path"
 	"sort"
 	"strings"
 
-	"github.com/podhmo/goinspect/graph"
 	"golang.org/x/tools/go/packages"
 )
 
@@ -17,24 +17,145 @@ type Config struct {
 	Fset *token.FileSet
 
 	PkgPath    string
+	PkgPaths   []string // the target packages, if multiple (PkgPath is used, if empty)
 	Padding    string
 	TrimPrefix string
 
 	ExpandAll         bool
 	IncludeUnexported bool
 	IncludeStruct     bool
+	IncludeReferences bool
+	IncludeInit       bool
+	IncludeTests      bool
 	OtherPackages     []string
 
+	Builds []string // the scanned build configurations, if ScanBuilds
+
+	ShowExternal            bool
+	ExternalPackages        []string // if not empty, only the matched external packages are shown
+	ExcludeExternalPackages []string
+
+	KeepGoing bool // scan the packages even if they have errors, and mark the affected nodes as incomplete
+
+	UncoveredOnly bool // dump only the paths reaching the uncovered functions (0%), after ApplyCoverage
+
+	Profile       *Profile       // set by ApplyProfile
+	ProfileReport *ProfileReport // set by ApplyProfile
+	MinCum        float64        // hide the functions under the percentage of the cumulative value, after ApplyProfile
+	SortByCost    bool           // sort the roots and the callees by the cumulative value, after ApplyProfile
+
+	TrackErrors       bool // record how the errors returned by the callees are handled (Subject.ErrorHandling)
+	TrackTerminations bool // record the calls terminating the process or panicking (Subject.Sinks), for ApplyTerminations
+	TrackLocks        bool // record the locks acquired and held (Subject.Locks, Subject.HeldLocks), for LockFindings
+	TrackContexts     bool // record the calls passing a new context (Subject.NewContexts), for ContextFindings
+	TrackComm         bool // record the goroutines and the channels (Subject.Spawns, Subject.Sends, Subject.Recvs), for CommMap
+
+	TrackEntryPoints bool        // record the functions and methods passed to the registrars (Subject.EntryPoints)
+	Registrars       []Registrar // the registrars of entry points, in addition to DefaultRegistrars
+	EntriesOnly      bool        // dump only the entry points as roots (Subject.EntryPoints)
+
+	Effects []EffectCategory // the categories of side effects (Subject.EffectCalls)
+
+	ShowMetrics bool // compute the metrics of the functions (Subject.Metrics), and show them with the totals reachable from them
+
 	Debug           bool
 	skipHeader      bool
 	forceIncludeMap map[string]bool
 }
 
+// Targets returns the target packages.
+func (c *Config) Targets() []string {
+	if len(c.PkgPaths) == 0 {
+		return []string{c.PkgPath}
+	}
+	return c.PkgPaths
+}
+
 func (c *Config) NeedName(name string) bool {
 	return c.IncludeUnexported || token.IsExported(name) || c.forceIncludeMap[name]
 }
 
+// NeedNode reports whether the function or method is shown, by its name and receiver. The entry points are always shown.
+func (c *Config) NeedNode(n *Node) bool {
+	return isEntryPoint(n) || (c.IncludeInit && isInit(n)) || (c.NeedName(n.Name) && (n.Value.Recv == "" || c.NeedName(n.Value.Recv)))
+}
+
+// isInit reports whether the node is the init() function or the synthetic <pkg>.init node of the package-level variables (not the method named init).
+func isInit(n *Node) bool {
+	return n.Value.Kind == KindFunc && n.Name == "init"
+}
+
+// NeedExternal reports whether the calls into the package outside of the loaded packages are shown as leaf nodes.
+func (c *Config) NeedExternal(pkgpath string) bool {
+	if !c.ShowExternal {
+		return false
+	}
+	for _, pattern := range c.ExcludeExternalPackages {
+		if matchPackage(pattern, pkgpath) {
+			return false
+		}
+	}
+	if len(c.ExternalPackages) == 0 {
+		return true
+	}
+	for _, pattern := range c.ExternalPackages {
+		if matchPackage(pattern, pkgpath) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPackage reports whether pkgpath matches pattern. pattern is a package path, a glob (e.g. net/*), or a path with /... suffix (e.g. net/http/...).
+func matchPackage(pattern string, pkgpath string) bool {
+	if prefix := strings.TrimSuffix(pattern, "/..."); prefix != pattern {
+		return pkgpath == prefix || strings.HasPrefix(pkgpath, prefix+"/")
+	}
+	ok, _ := path.Match(pattern, pkgpath)
+	return ok
+}
+
+// Scan scans the target packages and returns the call graph.
+// If the loaded packages have errors, all of them are returned as PackageErrors.
+// With Config.KeepGoing, the graph is returned together with PackageErrors.
 func Scan(c *Config, pkgs []*packages.Package) (*Graph, error) {
+	g := NewGraph()
+	if err := scan(g, c, pkgs, ""); err != nil {
+		if _, ok := err.(PackageErrors); ok && c.KeepGoing {
+			return g, err
+		}
+		return nil, err
+	}
+	return g, nil
+}
+
+// ScanBuilds scans the packages loaded with each build configuration, and merges them into one graph.
+// Each node and edge is tagged with the build configurations that it exists in (Subject.Builds, Subject.EdgeBuilds).
+func ScanBuilds(c *Config, builds []Build, load func(Build) ([]*packages.Package, error)) (*Graph, error) {
+	g := NewGraph()
+	c.Builds = nil
+	var errs PackageErrors
+	for _, b := range builds {
+		pkgs, err := load(b)
+		if err != nil {
+			return nil, fmt.Errorf("load packages (%s): %w", b, err)
+		}
+		if err := scan(g, c, pkgs, b.String()); err != nil {
+			pkgErrs, ok := err.(PackageErrors)
+			if !ok || !c.KeepGoing {
+				return nil, fmt.Errorf("scan (%s): %w", b, err)
+			}
+			errs = append(errs, pkgErrs...)
+		}
+		c.Builds = append(c.Builds, b.String())
+	}
+	if len(errs) > 0 {
+		return g, errs
+	}
+	return g, nil
+}
+
+func scan(g *Graph, c *Config, pkgs []*packages.Package, build string) error {
 	if c.Fset == nil {
 		c.Fset = token.NewFileSet()
 	}
@@ -42,26 +163,39 @@ func Scan(c *Config, pkgs []*packages.Package) (*Graph, error) {
 		c.forceIncludeMap = map[string]bool{}
 	}
 
-	g := graph.New(func(s *Subject) string { return s.ID })
-	pkgMap := make(map[string]*packages.Package, len(pkgs))
+	errs := collectErrors(pkgs)
+	if len(errs) > 0 && !c.KeepGoing {
+		return errs
+	}
+
+	pkgs = dedupPackages(pkgs)
+	pkgMap := make(map[string]bool, len(pkgs))
 	for _, pkg := range pkgs {
-		pkgMap[pkg.ID] = pkg
+		pkgMap[pkg.PkgPath] = true
 	}
 	scanner := &Scanner{
 		g:      g,
 		pkgMap: pkgMap,
+		build:  build,
+		errors: errorLines(errs),
 		Config: c,
 	}
 
-	matched := false
+	targets := make(map[string]bool, len(c.Targets()))
+	for _, pkgpath := range c.Targets() {
+		targets[pkgpath] = false
+		if c.IncludeTests {
+			targets[pkgpath+"_test"] = false
+		}
+	}
 	for _, pkg := range pkgs {
-		if pkg.PkgPath != c.PkgPath {
+		if _, ok := targets[pkg.PkgPath]; !ok {
 			continue
 		}
 
-		matched = true
-		if len(pkg.Errors) > 0 {
-			return nil, pkg.Errors[0] // TODO: multierror
+		targets[pkg.PkgPath] = true
+		if pkg.TypesInfo == nil {
+			continue // not type-checked (KeepGoing)
 		}
 
 		// when main package, include main() forcely.
@@ -72,21 +206,109 @@ func Scan(c *Config, pkgs []*packages.Package) (*Graph, error) {
 
 		for _, f := range pkg.Syntax {
 			if err := scanner.Scan(pkg, f); err != nil {
-				return nil, err
+				return err
 			}
 		}
 	}
-	if !matched {
-		return nil, fmt.Errorf("pkg is not found, %q", c.PkgPath)
+	for _, pkgpath := range c.Targets() {
+		if !targets[pkgpath] {
+			return fmt.Errorf("pkg is not found, %q", pkgpath)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
 	}
-	return g, nil
+	return nil
+}
+
+// dedupPackages deduplicates the package variants by package path.
+// When loaded with tests (packages.Config.Tests), the test variant (e.g. "x [x.test]") is preferred because it includes _test.go files,
+// and the generated test main package (e.g. "x.test") is dropped.
+func dedupPackages(pkgs []*packages.Package) []*packages.Package {
+	r := make([]*packages.Package, 0, len(pkgs))
+	seen := make(map[string]int, len(pkgs))
+	for _, pkg := range pkgs {
+		if pkg.Name == "main" && strings.HasSuffix(pkg.PkgPath, ".test") {
+			continue
+		}
+		if i, ok := seen[pkg.PkgPath]; ok {
+			if pkg.ID != pkg.PkgPath {
+				r[i] = pkg
+			}
+			continue
+		}
+		seen[pkg.PkgPath] = len(r)
+		r = append(r, pkg)
+	}
+	return r
 }
 
 func DumpAll(w io.Writer, c *Config, g *Graph) error {
-	return dump(w, c, g, g.Nodes, nil)
+	return dump(w, c, g, g.Nodes, nil, isRoot)
 }
 
 func Dump(w io.Writer, c *Config, g *Graph, nodes []*Node) error {
+	selected, seen := selectNodes(g, nodes)
+	return dump(w, c, g, selected, seen, isRoot)
+}
+
+// DumpPackages dumps the nodes of each target package, as a section with its own header.
+func DumpPackages(w io.Writer, c *Config, g *Graph) error {
+	for i, pkgpath := range c.Targets() {
+		if i > 0 {
+			fmt.Fprintln(w, "")
+		}
+		if err := DumpPackage(w, c, g, pkgpath); err != nil {
+			return fmt.Errorf("dump %s: %w", pkgpath, err)
+		}
+	}
+	return nil
+}
+
+// DumpPackage dumps the nodes of the package. The nodes only called from other packages are also treated as roots.
+func DumpPackage(w io.Writer, c *Config, g *Graph, pkgpath string) error {
+	copied := *c
+	copied.PkgPath = pkgpath
+	copied.PkgPaths = nil
+
+	return dump(w, &copied, g, g.Nodes, nil, packageRoot(pkgpath))
+}
+
+// packageRoot returns the function reporting whether the node is a root in the package (not called in the package).
+func packageRoot(pkgpath string) func(*Node) bool {
+	inPackage := func(n *Node) bool {
+		p := pkgPathOf(n)
+		return p == pkgpath || p == pkgpath+"_test"
+	}
+	return func(n *Node) bool {
+		if !inPackage(n) {
+			return false
+		}
+		if isEntryPoint(n) {
+			return true
+		}
+		for _, prev := range n.From {
+			if inPackage(prev) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func isRoot(n *Node) bool {
+	return len(n.From) == 0 || isEntryPoint(n)
+}
+
+func pkgPathOf(n *Node) string {
+	if n.Value.Object == nil || n.Value.Object.Pkg() == nil {
+		return ""
+	}
+	return n.Value.Object.Pkg().Path()
+}
+
+// selectNodes returns the roots reaching nodes, and the IDs of the nodes related to nodes (the callers and the callees, recursively).
+func selectNodes(g *Graph, nodes []*Node) ([]*Node, map[int]struct{}) {
 	selected := make([]*Node, 0, len(nodes))
 	seen := make(map[int]struct{}, len(g.Nodes))
 
@@ -130,92 +352,77 @@ func Dump(w io.Writer, c *Config, g *Graph, nodes []*Node) error {
 			}
 		}
 	}
-
-	return dump(w, c, g, selected, seen)
+	return selected, seen
 }
 
-func dump(w io.Writer, c *Config, g *Graph, nodes []*Node, filter map[int]struct{}) error {
-	pkgpath := c.PkgPath
-	expand := c.ExpandAll
+// DumpReverse dumps the callers of nodes recursively, instead of the callees (e.g. which tests reach the function?).
+func DumpReverse(w io.Writer, c *Config, g *Graph, nodes []*Node) error {
+	rows, sameIDRows := collectReverseRows(c, nodes)
 
-	rows := make([]*row, 0, len(nodes))
-	sameIDRows := map[int][]*row{}
-
-	parts := strings.Split(pkgpath, "/")
-	prefix := strings.Join(parts[:len(parts)-1], "/") + "/"
-
-	{
-		sorted := g.SortedByFrom(nodes)
-		sortedMap := make(map[int]int, len(sorted))
-		for i, n := range sorted {
-			sortedMap[n.ID] = i
+	if !c.skipHeader {
+		for _, pkgpath := range c.Targets() {
+			fmt.Fprintf(w, "package %s\n", pkgpath)
 		}
-		g.Walk(func(n *Node) {
-			if n.Value.Kind == KindObject {
-				if len(n.To) > 0 {
-					sort.SliceStable(n.To, func(i, j int) bool { return sortedMap[n.To[i].ID] < sortedMap[n.To[j].ID] })
-				}
-			}
-		})
 	}
+	dumpRows(w, c, rows, sameIDRows)
+	return nil
+}
 
-	prevIndent := 0
-	g.WalkPath(func(path []*Node) {
-		node := path[len(path)-1]
-		if filter != nil {
-			if _, ok := filter[node.ID]; !ok {
-				return
-			}
-			for _, x := range path[:len(path)-1] {
-				if _, ok := filter[x.ID]; !ok {
-					return
-				}
+// collectReverseRows returns the rows of the callers of nodes, and the rows grouped by node ID.
+func collectReverseRows(c *Config, nodes []*Node) ([]*row, map[int][]*row) {
+	prefix := textPrefix(c.PkgPath)
+	rows := make([]*row, 0, len(nodes))
+	sameIDRows := map[int][]*row{}
+	expanded := map[int]bool{}
+	totals := metricTotals{}
+
+	var walk func(n *Node, indent int, path []*Node, builds []string, errors []ErrorHandling)
+	walk = func(n *Node, indent int, path []*Node, builds []string, errors []ErrorHandling) {
+		isRecursive := false
+		for _, x := range path {
+			if x.ID == n.ID {
+				isRecursive = true
 			}
 		}
 
-		indent := len(path)
-		if indent == 1 {
-			if len(node.From) == 0 && c.NeedName(node.Name) && (node.Value.Recv == "" || c.NeedName(node.Value.Recv)) {
-				if node.Value.Kind == KindObject && len(node.To) == 0 {
-					return
-				}
-
-				text := strings.ReplaceAll(path[indent-1].Value.Object.String(), prefix, "")
-				if c.TrimPrefix != "" {
-					text = strings.ReplaceAll(text, c.TrimPrefix, "")
-				}
-
-				row := &row{indent: indent, name: node.Name, text: text, id: node.ID, kind: node.Value.Kind, hasChildren: len(node.To) > 0, isToplevel: true}
-				rows = append(rows, row)
-				sameIDRows[node.ID] = append(sameIDRows[node.ID], row)
-				prevIndent = row.indent
-			}
-		} else {
-			if (filter != nil || prevIndent == 0) && prevIndent < indent && indent-prevIndent > 1 { // for --only with sub nodes
-				return
-			}
-			if c.NeedName(node.Name) && (node.Value.Recv == "" || c.NeedName(node.Value.Recv)) {
-				text := strings.ReplaceAll(node.Value.Object.String(), prefix, "")
-				if c.TrimPrefix != "" {
-					text = strings.ReplaceAll(text, c.TrimPrefix, "")
-				}
+		// the callers of unexported symbols are shown, even if the symbols are hidden
+		if indent == 1 || c.NeedNode(n) {
+			row := newRow(c, n, indent, prefix, totals)
+			row.hasChildren = len(n.From) > 0
+			row.isRecursive = isRecursive
+			row.builds = partialBuilds(c, builds)
+			row.errors = errors
+			rows = append(rows, row)
+			sameIDRows[n.ID] = append(sameIDRows[n.ID], row)
+			indent++
+		}
+		if isRecursive || expanded[n.ID] {
+			return
+		}
+		expanded[n.ID] = true
 
-				isRecursive := false
-				for _, x := range path[:len(path)-1] {
-					if x.ID == node.ID {
-						isRecursive = true
-					}
-				}
-				row := &row{indent: indent, name: node.Name, text: text, id: node.ID, kind: node.Value.Kind, hasChildren: len(node.To) > 0, isRecursive: isRecursive}
-				rows = append(rows, row)
-				sameIDRows[node.ID] = append(sameIDRows[node.ID], row)
-				prevIndent = row.indent
+		path = append(path, n)
+		for _, prev := range n.From {
+			if prev.Value.Kind == KindObject {
+				continue
 			}
+			walk(prev, indent, path, prev.Value.EdgeBuilds[n.Value.ID], prev.Value.ErrorHandling[n.Value.ID])
 		}
-	}, nodes)
+	}
+	for _, n := range nodes {
+		walk(n, 1, nil, n.Value.Builds, nil)
+	}
+	return rows, sameIDRows
+}
+
+func dump(w io.Writer, c *Config, g *Graph, nodes []*Node, filter map[int]struct{}, isRoot func(*Node) bool) error {
+	expand := c.ExpandAll
+	rows, sameIDRows := collectRows(c, g, nodes, filter, isRoot)
 
 	if !c.skipHeader {
-		fmt.Fprintf(w, "package %s\n", pkgpath)
+		for _, pkgpath := range c.Targets() {
+			fmt.Fprintf(w, "package %s\n", pkgpath)
+		}
 	}
 
 	seen := make(map[int][]int, len(sameIDRows))
@@ -225,7 +432,11 @@ func dump(w io.Writer, c *Config, g *Graph, nodes []*Node, filter map[int]struct
 			idx := seen[row.id][0]
 			st := rows[idx]
 			seen[row.id] = append(seen[row.id], i)
-			emit(w, c, indent, st)
+			copied := *st // the edge-specific attributes are taken from the current row
+			copied.isRef = row.isRef
+			copied.builds = row.builds
+			copied.errors = row.errors
+			emit(w, c, indent, &copied)
 			if c.Debug {
 				fmt.Fprintf(w, "  // c *%d\n", st.id)
 			} else {
@@ -312,29 +523,7 @@ func dump(w io.Writer, c *Config, g *Graph, nodes []*Node, filter map[int]struct
 			}
 		}
 	} else {
-		for i, row := range rows {
-			if row.isToplevel {
-				fmt.Fprintln(w, "")
-			}
-			if showID := len(sameIDRows[row.id]) > 1; showID {
-				if len(seen[row.id]) == 0 {
-					emit(w, c, row.indent, row)
-					fmt.Fprintf(w, "  // &%d\n", row.id) // define reference
-				} else if row.isRecursive {
-					emit(w, c, row.indent, row)
-					fmt.Fprintf(w, "  // *%d recursion\n", row.id)
-				} else {
-					emit(w, c, row.indent, row)
-					fmt.Fprintf(w, "  // *%d\n", row.id) // use reference
-				}
-				seen[row.id] = append(seen[row.id], i)
-			} else {
-				emit(w, c, row.indent, row)
-				fmt.Fprintln(w, "")
-				seen[row.id] = append(seen[row.id], i)
-			}
-
-		}
+		dumpRows(w, c, rows, sameIDRows)
 	}
 
 	if c.Debug {
@@ -347,14 +536,208 @@ func dump(w io.Writer, c *Config, g *Graph, nodes []*Node, filter map[int]struct
 	return nil
 }
 
+// collectRows returns the rows of the call tree from the roots of nodes, and the rows grouped by node ID.
+func collectRows(c *Config, g *Graph, nodes []*Node, filter map[int]struct{}, isRoot func(*Node) bool) ([]*row, map[int][]*row) {
+	rows := make([]*row, 0, len(nodes))
+	sameIDRows := map[int][]*row{}
+	totals := metricTotals{}
+
+	prefix := textPrefix(c.PkgPath)
+
+	{
+		sorted := g.SortedByFrom(nodes)
+		sortedMap := make(map[int]int, len(sorted))
+		for i, n := range sorted {
+			sortedMap[n.ID] = i
+		}
+		g.Walk(func(n *Node) {
+			if n.Value.Kind == KindObject {
+				if len(n.To) > 0 {
+					sort.SliceStable(n.To, func(i, j int) bool { return sortedMap[n.To[i].ID] < sortedMap[n.To[j].ID] })
+				}
+			}
+		})
+	}
+
+	roots := make([]*Node, 0, len(nodes))
+	for _, n := range nodes {
+		if isRoot(n) && (!c.EntriesOnly || isEntryPoint(n)) {
+			roots = append(roots, n)
+		}
+	}
+	if c.Profile != nil && c.SortByCost {
+		byCost := func(nodes []*Node) {
+			sort.SliceStable(nodes, func(i, j int) bool { return c.Profile.cumPercent(nodes[i]) > c.Profile.cumPercent(nodes[j]) })
+		}
+		byCost(roots)
+		g.Walk(func(n *Node) { byCost(n.To) })
+	}
+
+	var uncovered map[int]struct{}
+	if c.UncoveredOnly {
+		uncovered = uncoveredPaths(g)
+	}
+
+	prevIndent := 0
+	g.WalkPathFrom(func(path []*Node) {
+		node := path[len(path)-1]
+		if uncovered != nil {
+			if _, ok := uncovered[node.ID]; !ok {
+				return
+			}
+		}
+		for _, x := range path {
+			if belowMinCum(c, x) {
+				return
+			}
+		}
+		if filter != nil {
+			if _, ok := filter[node.ID]; !ok {
+				return
+			}
+			for _, x := range path[:len(path)-1] {
+				if _, ok := filter[x.ID]; !ok {
+					return
+				}
+			}
+		}
+
+		indent := len(path)
+		if indent == 1 {
+			if c.NeedNode(node) {
+				if node.Value.Kind == KindObject && len(node.To) == 0 {
+					return
+				}
+
+				row := newRow(c, node, indent, prefix, totals)
+				row.builds = partialBuilds(c, node.Value.Builds)
+				rows = append(rows, row)
+				sameIDRows[node.ID] = append(sameIDRows[node.ID], row)
+				prevIndent = row.indent
+			}
+		} else {
+			if (filter != nil || prevIndent == 0) && prevIndent < indent && indent-prevIndent > 1 { // for --only with sub nodes
+				return
+			}
+			if c.NeedNode(node) {
+				isRecursive := false
+				for _, x := range path[:len(path)-1] {
+					if x.ID == node.ID {
+						isRecursive = true
+					}
+				}
+				parent := path[indent-2]
+				row := newRow(c, node, indent, prefix, totals)
+				row.isRecursive = isRecursive
+				row.isRef = parent.Value.Refs[node.Value.ID]
+				row.builds = partialBuilds(c, parent.Value.EdgeBuilds[node.Value.ID])
+				row.errors = parent.Value.ErrorHandling[node.Value.ID]
+				rows = append(rows, row)
+				sameIDRows[node.ID] = append(sameIDRows[node.ID], row)
+				prevIndent = row.indent
+			}
+		}
+	}, roots)
+	return rows, sameIDRows
+}
+
+// dumpRows writes rows with references. The first row of the same node is marked as "&N" and the others are marked as "*N".
+func dumpRows(w io.Writer, c *Config, rows []*row, sameIDRows map[int][]*row) {
+	seen := make(map[int]bool, len(sameIDRows))
+	for _, row := range rows {
+		if row.isToplevel {
+			fmt.Fprintln(w, "")
+		}
+		if showID := len(sameIDRows[row.id]) > 1; showID {
+			if !seen[row.id] {
+				emit(w, c, row.indent, row)
+				fmt.Fprintf(w, "  // &%d\n", row.id) // define reference
+			} else if row.isRecursive {
+				emit(w, c, row.indent, row)
+				fmt.Fprintf(w, "  // *%d recursion\n", row.id)
+			} else {
+				emit(w, c, row.indent, row)
+				fmt.Fprintf(w, "  // *%d\n", row.id) // use reference
+			}
+		} else {
+			emit(w, c, row.indent, row)
+			fmt.Fprintln(w, "")
+		}
+		seen[row.id] = true
+	}
+}
+
+// textPrefix returns the prefix trimmed from the text of nodes (the parent of pkgpath).
+func textPrefix(pkgpath string) string {
+	parts := strings.Split(pkgpath, "/")
+	return strings.Join(parts[:len(parts)-1], "/") + "/"
+}
+
+func nodeText(c *Config, node *Node, prefix string) string {
+	text := strings.ReplaceAll(node.Value.Object.String(), prefix, "")
+	if c.TrimPrefix != "" {
+		text = strings.ReplaceAll(text, c.TrimPrefix, "")
+	}
+	return text
+}
+
+// partialBuilds returns builds, only if the node or edge does not exist in all of the scanned build configurations.
+func partialBuilds(c *Config, builds []string) []string {
+	if len(builds) == len(c.Builds) {
+		return nil
+	}
+	return builds
+}
+
 func emit(w io.Writer, c *Config, indent int, row *row) {
+	text := rowText(row)
 	if c.Debug {
-		fmt.Fprintf(w, "%3d: %s%s", indent, strings.Repeat(c.Padding, indent), row.text)
+		fmt.Fprintf(w, "%3d: %s%s", indent, strings.Repeat(c.Padding, indent), text)
 	} else {
-		fmt.Fprintf(w, "%s%s", strings.Repeat(c.Padding, indent), row.text)
+		fmt.Fprintf(w, "%s%s", strings.Repeat(c.Padding, indent), text)
 	}
 }
 
+// rowText returns the text of row with the annotations of the node and the edge.
+func rowText(row *row) string {
+	text := row.text
+	if row.isRef {
+		text = "ref " + text
+	}
+	if len(row.builds) > 0 {
+		text += "  @" + strings.Join(row.builds, ",")
+	}
+	if len(row.errors) > 0 {
+		errors := make([]string, len(row.errors))
+		for i, h := range row.errors {
+			errors[i] = string(h)
+		}
+		text += "  err:" + strings.Join(errors, ",")
+	}
+	if row.incomplete {
+		text += "  !incomplete"
+	}
+	if row.cover != nil {
+		text += fmt.Sprintf("  cover:%.1f%%", row.cover.Percent())
+	}
+	if row.cost != nil {
+		text += fmt.Sprintf("  flat:%.1f%% cum:%.1f%%", row.cost.flat, row.cost.cum)
+	}
+	if len(row.effects) > 0 {
+		text += "  [" + strings.Join(row.effects, ",") + "]"
+	}
+	if row.termination != nil {
+		text += "  " + terminationText(row.termination)
+	}
+	if row.metrics != nil {
+		text += "  " + metricsText(row.metrics)
+	}
+	if len(row.entries) > 0 {
+		text += "  entry:" + strings.Join(row.entries, ",")
+	}
+	return text
+}
+
 type row struct {
 	indent int
 	name   string
@@ -365,4 +748,46 @@ type row struct {
 	hasChildren bool
 	isToplevel  bool
 	isRecursive bool
+	isRef       bool            // used as a function value, not called
+	builds      []string        // the build configurations, if the node (or edge) does not exist in all of them
+	errors      []ErrorHandling // how the errors of the edge are handled, if Config.TrackErrors
+	incomplete  bool
+	cover       *Cover       // the coverage of the node, if ApplyCoverage
+	cost        *rowCost     // the percentages of the sampled values, if ApplyProfile
+	termination *Termination // if ApplyTerminations
+	entries     []string     // the registrars of the entry point
+	effects     []string     // the categories of side effects, if ApplyEffects
+	metrics     *rowMetrics  // if Config.ShowMetrics
+}
+
+// newRow returns the row of the node, the attributes of the edge from the parent (e.g. isRef, builds, errors) are set by the caller.
+func newRow(c *Config, n *Node, indent int, prefix string, totals metricTotals) *row {
+	return &row{
+		indent:      indent,
+		name:        n.Name,
+		text:        nodeText(c, n, prefix),
+		id:          n.ID,
+		kind:        n.Value.Kind,
+		hasChildren: len(n.To) > 0,
+		isToplevel:  indent == 1,
+		incomplete:  n.Value.Incomplete,
+		cover:       n.Value.Cover,
+		cost:        newRowCost(c, n),
+		termination: n.Value.Termination,
+		entries:     entryTexts(n, prefix),
+		effects:     effectNames(n),
+		metrics:     newRowMetrics(c, n, totals),
+	}
+}
+
+type rowCost struct {
+	flat float64
+	cum  float64
+}
+
+func newRowCost(c *Config, n *Node) *rowCost {
+	if c.Profile == nil || n.Value.Cost == nil {
+		return nil
+	}
+	return &rowCost{flat: c.Profile.Percent(n.Value.Cost.Flat), cum: c.Profile.Percent(n.Value.Cost.Cum)}
 }
@@ -1,12 +1,20 @@
 package goinspect
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"go/token"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/pprof/profile"
+	"github.com/podhmo/goinspect/graph"
 	"golang.org/x/tools/go/packages"
 )
 
@@ -24,19 +32,7 @@ func TestIT(t *testing.T) {
 		skipHeader:        true,
 	}
 
-	cfg := &packages.Config{
-		Fset: fset,
-		Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedImports | packages.NeedTypes | packages.NeedTypesSizes | packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedDeps,
-	}
-	pkgs, err := packages.Load(cfg, append([]string{c.PkgPath}, c.OtherPackages...)...)
-	if err != nil {
-		t.Errorf("unexpected error: %+v", err)
-	}
-
-	g, err := Scan(c, pkgs)
-	if err != nil {
-		t.Errorf("unexpected error: %+v", err)
-	}
+	g := loadAndScan(t, c)
 
 	testcases := []struct {
 		msg   string
@@ -64,28 +60,1382 @@ func TestIT(t *testing.T) {
 @@@func x.log() func()  // *3
 @@@func x.H()
 @@func x/sub.X()`,
-			},
+		},
 	}
 
 	for _, tc := range testcases {
 		t.Run(tc.msg, func(t *testing.T) {
-			buf := new(bytes.Buffer)
+			assertDump(t, c, g, tc.names, tc.want)
+		})
+	}
+}
+
+func TestIncludeReferences(t *testing.T) {
+	pkg := "github.com/podhmo/goinspect/internal/x"
+	fset := token.NewFileSet()
+	c := &Config{
+		Fset:    fset,
+		PkgPath: pkg,
+		OtherPackages: []string{
+			"github.com/podhmo/goinspect/internal/x/sub",
+		},
+		Padding:           "@",
+		IncludeReferences: true,
+		skipHeader:        true,
+	}
+	g := loadAndScan(t, c)
+
+	want := `
+@func x.Register()
+@@func x.Handle(fn func(x.S))
+@@ref func (*x.Handler).Serve(s x.S)
+@@@func x.H()  // &5
+@@ref func x.F(s x.S)
+@@@func x.F0()
+@@@@func x.F1()
+@@@@@func x.H()  // *5
+@@@func x.H()  // *5
+@@ref func x/sub.X()
+@@ref func x.G0()
+@@@func x.H()  // *5`
+	assertDump(t, c, g, []string{"Register"}, want)
+}
+
+func TestIncludeInit(t *testing.T) {
+	pkg := "github.com/podhmo/goinspect/internal/x/inits"
+	fset := token.NewFileSet()
+	c := &Config{
+		Fset:    fset,
+		PkgPath: pkg,
+		OtherPackages: []string{
+			"github.com/podhmo/goinspect/internal/x/sub",
+		},
+		Padding:     "@",
+		IncludeInit: true,
+		skipHeader:  true,
+	}
+	g := loadAndScan(t, c)
+
+	want := `
+@var inits.init func()
+@@func inits.NewClient() *inits.Client
+
+@func inits.init()
+@@func inits.Setup()
+@@@func inits.Register(name string)
+
+@func inits.init()
+@@func (*inits.Client).Register(name string)
+@@@func sub.X()`
+	assertDump(t, c, g, []string{"init"}, want)
+}
+
+func TestImportName(t *testing.T) {
+	pkg := "github.com/podhmo/goinspect/internal/importname"
+	fset := token.NewFileSet()
+	c := &Config{
+		Fset:    fset,
+		PkgPath: pkg,
+		OtherPackages: []string{
+			"github.com/podhmo/goinspect/internal/importname/...",
+		},
+		Padding:    "@",
+		skipHeader: true,
+	}
+	g := loadAndScan(t, c)
+
+	want := `
+@func importname.Run()
+@@func importname/foo/v2.Foo()
+@@func importname/go-sqlite3.Open()
+@@func importname/yaml.v3.Marshal()
+@@func importname/sub.Sub()
+@@func importname/dot.Dot()`
+	assertDump(t, c, g, []string{"Run"}, want)
+}
+
+func TestDumpPackages(t *testing.T) {
+	fset := token.NewFileSet()
+	c := &Config{
+		Fset: fset,
+		PkgPaths: []string{
+			"github.com/podhmo/goinspect/internal/importname",
+			"github.com/podhmo/goinspect/internal/importname/dot",
+		},
+		Padding: "@",
+	}
+	c.PkgPath = c.PkgPaths[0]
+	c.OtherPackages = c.PkgPaths[1:]
+	g := loadAndScan(t, c)
+
+	want := `
+package github.com/podhmo/goinspect/internal/importname
+
+@func importname.Run()
+@@func importname/dot.Dot()
+
+package github.com/podhmo/goinspect/internal/importname/dot
+
+@func dot.Dot()`
+
+	buf := new(bytes.Buffer)
+	if err := DumpPackages(buf, c, g); err != nil {
+		t.Errorf("unexpected error: %+v", err)
+	}
+	if diff := cmp.Diff(strings.TrimSpace(want), strings.TrimSpace(buf.String())); diff != "" {
+		t.Errorf("DumpPackages() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestKeepGoing(t *testing.T) {
+	pkg := "github.com/podhmo/goinspect/internal/testdata/broken"
+	fset := token.NewFileSet()
+	c := &Config{
+		Fset:       fset,
+		PkgPath:    pkg,
+		Padding:    "@",
+		skipHeader: true,
+	}
+	cfg := &packages.Config{
+		Fset: c.Fset,
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedImports | packages.NeedTypes | packages.NeedTypesSizes | packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedDeps,
+	}
+	pkgs, err := packages.Load(cfg, c.PkgPath, c.PkgPath+"/sub")
+	if err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := Scan(c, pkgs)
+		errs, ok := err.(PackageErrors)
+		if !ok {
+			t.Fatalf("Scan() error, want PackageErrors but got %#+v", err)
+		}
+		if want, got := 4, len(errs); want != got {
+			t.Errorf("Scan() the number of errors, want %d but got %d\n%+v", want, got, err)
+		}
+	})
+
+	t.Run("keep-going", func(t *testing.T) {
+		c := *c
+		c.KeepGoing = true
+		g, err := Scan(&c, pkgs)
+		if _, ok := err.(PackageErrors); !ok {
+			t.Fatalf("Scan() error, want PackageErrors but got %#+v", err)
+		}
+
+		want := `
+@func broken.F()  !incomplete
+@@func broken.G()
+@@func broken.H(n int)
+@@func broken/sub.X()`
+		assertDump(t, &c, g, []string{"F"}, want)
+
+		// the calls to the undefined functions (e.g. sub.Undefined()) are skipped
+		if err := DumpAll(io.Discard, &c, g); err != nil {
+			t.Errorf("DumpAll() unexpected error: %+v", err)
+		}
+		if err := DumpAllJSON(io.Discard, &c, g); err != nil {
+			t.Errorf("DumpAllJSON() unexpected error: %+v", err)
+		}
+	})
+}
+
+func TestEntryPoints(t *testing.T) {
+	pkg := "github.com/podhmo/goinspect/internal/entries"
+	r, err := ParseRegistrar(pkg + ".Command.Run")
+	if err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+	c := &Config{
+		Fset:             token.NewFileSet(),
+		PkgPath:          pkg,
+		Padding:          "@",
+		Registrars:       []Registrar{r},
+		TrackEntryPoints: true,
+		EntriesOnly:      true,
+		skipHeader:       true,
+	}
+	g := loadAndScan(t, c)
+
+	want := `
+@func entries.index(w net/http.ResponseWriter, r *net/http.Request)  entry:net/http.HandleFunc
+@@func entries.Render(w net/http.ResponseWriter)  // &9
+
+@func (*entries.users).list(w net/http.ResponseWriter, r *net/http.Request)  entry:net/http.ServeMux#HandleFunc
+@@func entries.Render(w net/http.ResponseWriter)  // *9
+
+@func entries.health(w net/http.ResponseWriter, r *net/http.Request)  entry:net/http.ServeMux#Handle
+
+@func (*entries.api).ServeHTTP(w net/http.ResponseWriter, r *net/http.Request)  entry:net/http.ServeMux#Handle
+@@func entries.Render(w net/http.ResponseWriter)  // *9
+
+@func entries.serve(args []string) error  entry:entries.Command.Run
+
+@func entries.migrate(args []string) error  entry:entries.Command.Run`
+	buf := new(bytes.Buffer)
+	if err := DumpAll(buf, c, g); err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+	if diff := cmp.Diff(strings.TrimSpace(want), strings.TrimSpace(buf.String())); diff != "" {
+		t.Errorf("DumpAll() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseRegistrar(t *testing.T) {
+	cases := []struct {
+		msg     string
+		input   string
+		want    Registrar
+		wantErr bool
+	}{
+		{msg: "func", input: "net/http.HandleFunc:1", want: Registrar{Name: "net/http.HandleFunc", Arg: 1}},
+		{msg: "method", input: "net/http.ServeMux#Handle:1", want: Registrar{Name: "net/http.ServeMux#Handle", Arg: 1}},
+		{msg: "field", input: "github.com/spf13/cobra.Command.RunE", want: Registrar{Name: "github.com/spf13/cobra.Command.RunE", Arg: -1}},
+		{msg: "method-without-arg", input: "net/http.ServeMux#Handle", wantErr: true},
+		{msg: "invalid-arg", input: "net/http.HandleFunc:x", wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.msg, func(t *testing.T) {
+			got, err := ParseRegistrar(c.input)
+			if c.wantErr {
+				if err == nil {
+					t.Errorf("ParseRegistrar(%q), want error but got %+v", c.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRegistrar(%q), unexpected error: %+v", c.input, err)
+			}
+			if got != c.want {
+				t.Errorf("ParseRegistrar(%q) = %+v, want %+v", c.input, got, c.want)
+			}
+		})
+	}
+}
+
+func TestShowExternal(t *testing.T) {
+	pkg := "github.com/podhmo/goinspect/internal/external"
+	fset := token.NewFileSet()
+	c := &Config{
+		Fset:                    fset,
+		PkgPath:                 pkg,
+		Padding:                 "@",
+		ShowExternal:            true,
+		ExternalPackages:        []string{"net/..."},
+		ExcludeExternalPackages: []string{"net/url"},
+		skipHeader:              true,
+	}
+	g := loadAndScan(t, c)
+
+	want := `
+@func external.Fetch(url string) (*net/http.Response, error)
+@@func net/http.Get(url string) (resp *net/http.Response, err error)`
+	assertDump(t, c, g, []string{"Fetch"}, want)
+}
+
+func TestNeedExternal(t *testing.T) {
+	cases := []struct {
+		msg     string
+		c       *Config
+		pkgpath string
+		want    bool
+	}{
+		{msg: "disabled", c: &Config{}, pkgpath: "os", want: false},
+		{msg: "all", c: &Config{ShowExternal: true}, pkgpath: "os", want: true},
+		{msg: "allow", c: &Config{ShowExternal: true, ExternalPackages: []string{"database/sql"}}, pkgpath: "database/sql", want: true},
+		{msg: "allow-not-matched", c: &Config{ShowExternal: true, ExternalPackages: []string{"database/sql"}}, pkgpath: "os", want: false},
+		{msg: "allow-subpackages", c: &Config{ShowExternal: true, ExternalPackages: []string{"net/..."}}, pkgpath: "net/http", want: true},
+		{msg: "allow-glob", c: &Config{ShowExternal: true, ExternalPackages: []string{"net/*"}}, pkgpath: "net/http", want: true},
+		{msg: "deny", c: &Config{ShowExternal: true, ExternalPackages: []string{"net/..."}, ExcludeExternalPackages: []string{"net/url"}}, pkgpath: "net/url", want: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.msg, func(t *testing.T) {
+			if got := c.c.NeedExternal(c.pkgpath); got != c.want {
+				t.Errorf("NeedExternal(%q) = %v, want %v", c.pkgpath, got, c.want)
+			}
+		})
+	}
+}
+
+func TestIncludeTests(t *testing.T) {
+	pkg := "github.com/podhmo/goinspect/internal/testroots"
+	fset := token.NewFileSet()
+	c := &Config{
+		Fset:         fset,
+		PkgPath:      pkg,
+		Padding:      "@",
+		IncludeTests: true,
+		skipHeader:   true,
+	}
+	g := loadAndScan(t, c)
+
+	t.Run("roots", func(t *testing.T) {
+		want := `
+@func testroots.TestF0(t *testing.T)
+@@func testroots.F0()
+@@@func testroots.F1()
+@@@@func testroots.H()
+
+@func testroots_test.FuzzR(f *testing.F)
+@@func testroots.R(n int) int
+@@@func testroots.H()
+@@@func testroots.R(n int) int  // recursion`
+		c := *c
+		c.ExpandAll = true
+		assertDump(t, &c, g, []string{"TestF0", "FuzzR"}, want)
+	})
+
+	t.Run("reverse", func(t *testing.T) {
+		want := `
+@func testroots.R(n int) int  // &5
+@@func testroots.R(n int) int  // *5 recursion
+@@func testroots.RecRoot(n int)
+@@func testroots_test.FuzzR(f *testing.F)`
+
+		buf := new(bytes.Buffer)
+		var nodes []*Node
+		g.Walk(func(n *Node) {
+			if n.Name == "R" {
+				nodes = append(nodes, n)
+			}
+		})
+		if err := DumpReverse(buf, c, g, nodes); err != nil {
+			t.Errorf("unexpected error: %+v", err)
+		}
+		if diff := cmp.Diff(strings.TrimSpace(want), strings.TrimSpace(buf.String())); diff != "" {
+			t.Errorf("DumpReverse() mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestScanBuilds(t *testing.T) {
+	pkg := "github.com/podhmo/goinspect/internal/buildtags"
+	fset := token.NewFileSet()
+	c := &Config{
+		Fset:       fset,
+		PkgPath:    pkg,
+		Padding:    "@",
+		skipHeader: true,
+	}
+
+	builds := []Build{
+		{GOOS: "linux", GOARCH: "amd64"},
+		{GOOS: "windows", GOARCH: "amd64", Tags: []string{"integration"}},
+	}
+	g, err := ScanBuilds(c, builds, func(b Build) ([]*packages.Package, error) {
+		cfg := &packages.Config{
+			Fset: c.Fset,
+			Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedImports | packages.NeedTypes | packages.NeedTypesSizes | packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedDeps,
+		}
+		b.Apply(cfg)
+		return packages.Load(cfg, c.PkgPath)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+
+	want := `
+@func buildtags.Integration()  @windows/amd64:integration
+@@func buildtags.Run()  @windows/amd64:integration
+@@@func buildtags.Platform()
+@@@@func buildtags.Linux()  @linux/amd64
+@@@@func buildtags.Windows()  @windows/amd64:integration`
+	assertDump(t, c, g, []string{"Platform"}, want)
+}
+
+func TestParseBuild(t *testing.T) {
+	cases := []struct {
+		input string
+		want  Build
+	}{
+		{input: "linux/amd64", want: Build{GOOS: "linux", GOARCH: "amd64"}},
+		{input: "windows/amd64:integration", want: Build{GOOS: "windows", GOARCH: "amd64", Tags: []string{"integration"}}},
+		{input: ":integration+e2e", want: Build{Tags: []string{"integration", "e2e"}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			got, err := ParseBuild(c.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %+v", err)
+			}
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Errorf("ParseBuild() mismatch (-want +got):\n%s", diff)
+			}
+			if got.String() != c.input {
+				t.Errorf("String() = %q, want %q", got.String(), c.input)
+			}
+		})
+	}
+
+	if _, err := ParseBuild("linux"); err == nil {
+		t.Errorf("ParseBuild(%q), expected error", "linux")
+	}
+}
+
+func loadAndScan(t *testing.T, c *Config) *Graph {
+	t.Helper()
+	cfg := &packages.Config{
+		Fset:  c.Fset,
+		Mode:  packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedImports | packages.NeedTypes | packages.NeedTypesSizes | packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedDeps,
+		Tests: c.IncludeTests,
+	}
+	pkgs, err := packages.Load(cfg, append([]string{c.PkgPath}, c.OtherPackages...)...)
+	if err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+
+	g, err := Scan(c, pkgs)
+	if err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+	return g
+}
+
+func assertDump(t *testing.T, c *Config, g *Graph, names []string, want string) {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	var nodes []*Node
+	g.Walk(func(n *Node) {
+		for _, name := range names {
+			if name == n.Name {
+				nodes = append(nodes, n)
+			}
+		}
+	})
+
+	if err := Dump(buf, c, g, nodes); err != nil {
+		t.Errorf("unexpected error: %+v", err)
+	}
+
+	if diff := cmp.Diff(strings.TrimSpace(want), strings.TrimSpace(buf.String())); diff != "" {
+		t.Errorf("Scan() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTUI(t *testing.T) {
+	pkg := "github.com/podhmo/goinspect/internal/x"
+	fset := token.NewFileSet()
+	c := &Config{
+		Fset:       fset,
+		PkgPath:    pkg,
+		Padding:    "@",
+		skipHeader: true,
+	}
+	g := loadAndScan(t, c)
+
+	var nodes []*Node
+	g.Walk(func(n *Node) {
+		if n.Name == "RecRoot" {
+			nodes = append(nodes, n)
+		}
+	})
+
+	testcases := []struct {
+		msg  string
+		keys []string
+		want string
+	}{
+		{
+			msg: "initial",
+			want: `
+>+ func x.RecRoot(n int)`,
+		},
+		{
+			msg:  "expand",
+			keys: []string{"l", "j", "l"},
+			want: `
+- func x.RecRoot(n int)
+>@- func x.R(n int) int  // &24
+@@  func x.H()  // &5
+@@  func x.R(n int) int  // *24 recursion
+@+ func x.Odd(n int) bool  // &25`,
+		},
+		{
+			msg:  "collapse",
+			keys: []string{"l", "j", "l", "h", "h"},
+			want: `
+>- func x.RecRoot(n int)
+@+ func x.R(n int) int  // &24
+@+ func x.Odd(n int) bool  // &25`,
+		},
+		{
+			msg:  "jump",
+			keys: []string{"l", "j", "j", "l", "j", "*"},
+			want: `
+- func x.RecRoot(n int)
+@- func x.R(n int) int  // &24
+>@@  func x.H()  // &5
+@@  func x.R(n int) int  // *24 recursion
+@- func x.Odd(n int) bool  // &25
+@@  func x.H()  // *5
+@@+ func x.Even(n int) bool`,
+		},
+		{
+			msg:  "jump-next-reference",
+			keys: []string{"l", "j", "l", "j", "*", "*", "*"},
+			want: `
+- func x.RecRoot(n int)
+@- func x.R(n int) int  // &24
+@@  func x.H()  // &5
+@@  func x.R(n int) int  // *24 recursion
+@- func x.Odd(n int) bool  // &25
+@@  func x.H()  // *5
+@@- func x.Even(n int) bool
+>@@@  func x.H()  // *5
+@@@  func x.Odd(n int) bool  // *25 recursion`,
+		},
+		{
+			msg:  "search",
+			keys: []string{"/", "E", "v", "enter"},
+			want: `
+- func x.RecRoot(n int)
+@+ func x.R(n int) int  // &24
+@- func x.Odd(n int) bool  // &25
+@@  func x.H()  // *5
+>@@+ func x.Even(n int) bool`,
+		},
+		{
+			msg:  "callers",
+			keys: []string{"/", "E", "v", "enter", "r"},
+			want: `
+>- func x.Even(n int) bool  // &26
+@+ func x.Odd(n int) bool`,
+		},
+		{
+			msg:  "callers-back",
+			keys: []string{"/", "E", "v", "enter", "r", "b"},
+			want: `
+- func x.RecRoot(n int)
+@+ func x.R(n int) int  // &24
+@- func x.Odd(n int) bool  // &25
+@@  func x.H()  // *5
+>@@+ func x.Even(n int) bool`,
+		},
+		{
+			msg:  "callees",
+			keys: []string{"/", "E", "v", "enter", "c"},
+			want: `
+>- func x.Even(n int) bool  // &26
+@  func x.H()  // &5
+@+ func x.Odd(n int) bool`,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.msg, func(t *testing.T) {
+			tui := NewTUI(c, g, nodes)
+			for _, key := range tc.keys {
+				tui.handle(key)
+			}
+			got := strings.Join(tui.lines(), "\n")
+			if diff := cmp.Diff(strings.TrimSpace(tc.want), strings.TrimSpace(got)); diff != "" {
+				t.Errorf("TUI mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDumpHTML(t *testing.T) {
+	pkg := "github.com/podhmo/goinspect/internal/x"
+	fset := token.NewFileSet()
+	c := &Config{
+		Fset:    fset,
+		PkgPath: pkg,
+		Padding: "@",
+	}
+	g := loadAndScan(t, c)
+
+	var nodes []*Node
+	g.Walk(func(n *Node) {
+		if n.Name == "RecRoot" {
+			nodes = append(nodes, n)
+		}
+	})
+	buf := new(bytes.Buffer)
+	if err := DumpHTML(buf, c, g, nodes); err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+	got := buf.String()
+
+	for _, want := range []string{
+		`<details id="s0-n24" data-name="R"><summary><span class="text">func x.R(n int) int</span> <span class="ref">// &amp;24</span> <span class="pos">rec.go:3</span></summary>`, // definition
+		`<span class="ref">// <a href="#s0-n24">*24 recursion</a></span>`,                   // reference
+		`<pre class="src">func Even(n int) bool {`,                                          // source
+		`<div class="callers">called by <a href="#s0-n25">func x.Odd(n int) bool</a></div>`, // callers
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("DumpHTML() does not include %q", want)
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	pkg := "github.com/podhmo/goinspect/internal/x"
+	fset := token.NewFileSet()
+	c := &Config{
+		Fset:    fset,
+		PkgPath: pkg,
+		Padding: "@",
+	}
+	g := loadAndScan(t, c)
+	ts := httptest.NewServer(NewServer(c, g))
+	defer ts.Close()
+
+	get := func(t *testing.T, path string, code int) string {
+		t.Helper()
+		res, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("unexpected error: %+v", err)
+		}
+		defer res.Body.Close()
+		b, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatalf("unexpected error: %+v", err)
+		}
+		if res.StatusCode != code {
+			t.Errorf("GET %s: status code, want %d but got %d (%s)", path, code, res.StatusCode, b)
+		}
+		return string(b)
+	}
+	texts := func(t *testing.T, body string) []string {
+		t.Helper()
+		var v struct {
+			Nodes []jsonNode `json:"nodes"`
+		}
+		if err := json.Unmarshal([]byte(body), &v); err != nil {
+			t.Fatalf("unexpected error: %+v", err)
+		}
+		r := make([]string, len(v.Nodes))
+		for i, n := range v.Nodes {
+			r[i] = fmt.Sprintf("%d %s", n.ID, n.Text)
+		}
+		return r
+	}
+
+	testcases := []struct {
+		msg  string
+		path string
+		want []string
+	}{
+		{msg: "nodes", path: "/api/nodes?q=Even", want: []string{"26 func x.Even(n int) bool"}},
+		{msg: "callees", path: "/api/nodes/26/callees", want: []string{"5 func x.H()", "25 func x.Odd(n int) bool"}},
+		{msg: "callers", path: "/api/nodes/26/callers", want: []string{"25 func x.Odd(n int) bool"}},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.msg, func(t *testing.T) {
+			got := texts(t, get(t, tc.path, http.StatusOK))
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("GET %s mismatch (-want +got):\n%s", tc.path, diff)
+			}
+		})
+	}
+
+	t.Run("paths", func(t *testing.T) {
+		want := `{"paths":[[27,24,5],[27,25,5],[27,25,26,5]]}`
+		got := get(t, "/api/paths?from=27&to=5", http.StatusOK)
+		if diff := cmp.Diff(want, strings.Join(strings.Fields(got), "")); diff != "" {
+			t.Errorf("GET /api/paths mismatch (-want +got):\n%s", diff)
+		}
+	})
+	t.Run("source", func(t *testing.T) {
+		got := get(t, "/api/nodes/5/source", http.StatusOK)
+		if want := `"source": "func H() {\n\tprintln(\"H\")\n}"`; !strings.Contains(got, want) {
+			t.Errorf("GET /api/nodes/5/source does not include %q\n%s", want, got)
+		}
+	})
+	t.Run("not found", func(t *testing.T) {
+		get(t, "/api/nodes/9999", http.StatusNotFound)
+	})
+}
+
+func TestDumpDiff(t *testing.T) {
+	pkg := "github.com/podhmo/goinspect/internal/x"
+	c := &Config{
+		Fset:    token.NewFileSet(),
+		PkgPath: pkg,
+		Padding: "@",
+	}
+	prev := loadAndScan(t, c)
+
+	c2 := *c
+	c2.Fset = token.NewFileSet()
+	c2.IncludeReferences = true
+	g := loadAndScan(t, &c2)
+
+	want := `
++ func x.Register() -> ref func (*x.Handler).Serve(s x.S)
++ func x.Register() -> ref func x.F(s x.S)
++ func x.Register() -> ref func x.G0()`
+	buf := new(bytes.Buffer)
+	if err := DumpDiff(buf, c, prev, g); err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+	if diff := cmp.Diff(strings.TrimSpace(want), strings.TrimSpace(buf.String())); diff != "" {
+		t.Errorf("DumpDiff() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLSPServer(t *testing.T) {
+	pkg := "github.com/podhmo/goinspect/internal/x"
+	c := &Config{
+		Fset:    token.NewFileSet(),
+		PkgPath: pkg,
+		Padding: "@",
+	}
+	g := loadAndScan(t, c)
+
+	var filename string
+	g.Walk(func(n *Node) {
+		if n.Name == "Even" {
+			filename = c.Fset.Position(n.Value.Object.Pos()).Filename
+		}
+	})
+	uri := filenameToURI(filename)
+	position := `{"textDocument": {"uri": "` + uri + `"}, "position": {"line": 20, "character": 2}}` // in the body of Even()
+
+	in := new(bytes.Buffer)
+	for _, body := range []string{
+		`{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {}}`,
+		`{"jsonrpc": "2.0", "method": "initialized", "params": {}}`,
+		`{"jsonrpc": "2.0", "id": 2, "method": "textDocument/prepareCallHierarchy", "params": ` + position + `}`,
+		`{"jsonrpc": "2.0", "id": 3, "method": "callHierarchy/outgoingCalls", "params": {"item": {"name": "Even", "data": {"id": 26}}}}`,
+		`{"jsonrpc": "2.0", "id": 4, "method": "callHierarchy/incomingCalls", "params": {"item": {"name": "Even", "data": {"id": 26}}}}`,
+		`{"jsonrpc": "2.0", "id": 5, "method": "workspace/executeCommand", "params": {"command": "goinspect.tree", "arguments": [` + position + `]}}`,
+		`{"jsonrpc": "2.0", "id": 6, "method": "unknown"}`,
+		`{"jsonrpc": "2.0", "id": 7, "method": `, // malformed
+		`{"jsonrpc": "2.0", "id": 8, "method": "shutdown"}`,
+		`{"jsonrpc": "2.0", "method": "exit"}`,
+	} {
+		fmt.Fprintf(in, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	}
+	out := new(bytes.Buffer)
+	if err := NewLSPServer(c, g).Serve(in, out); err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+
+	type response struct {
+		ID     int             `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  *lspError       `json:"error"`
+	}
+	var responses []response
+	r := bufio.NewReader(out)
+	for {
+		body, err := readLSPMessage(r)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("unexpected error: %+v", err)
+		}
+		var res response
+		if err := json.Unmarshal(body, &res); err != nil {
+			t.Fatalf("unexpected error: %+v", err)
+		}
+		responses = append(responses, res)
+	}
+	if len(responses) != 8 {
+		t.Fatalf("the number of responses, want 8 but got %d", len(responses))
+	}
+
+	t.Run("prepareCallHierarchy", func(t *testing.T) {
+		var items []lspCallHierarchyItem
+		if err := json.Unmarshal(responses[1].Result, &items); err != nil {
+			t.Fatalf("unexpected error: %+v", err)
+		}
+		want := []string{"26 Even func x.Even(n int) bool 19:5-19:9"}
+		var got []string
+		for _, x := range items {
+			r := x.SelectionRange
+			got = append(got, fmt.Sprintf("%d %s %s %d:%d-%d:%d", x.Data.ID, x.Name, x.Detail, r.Start.Line, r.Start.Character, r.End.Line, r.End.Character))
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("prepareCallHierarchy mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	calls := func(t *testing.T, res response) []string {
+		t.Helper()
+		var calls []lspCallHierarchyCall
+		if err := json.Unmarshal(res.Result, &calls); err != nil {
+			t.Fatalf("unexpected error: %+v", err)
+		}
+		var r []string
+		for _, x := range calls {
+			item := x.To
+			if item == nil {
+				item = x.From
+			}
+			r = append(r, item.Detail)
+		}
+		return r
+	}
+	t.Run("outgoingCalls", func(t *testing.T) {
+		want := []string{"func x.H()", "func x.Odd(n int) bool"}
+		if diff := cmp.Diff(want, calls(t, responses[2])); diff != "" {
+			t.Errorf("outgoingCalls mismatch (-want +got):\n%s", diff)
+		}
+	})
+	t.Run("incomingCalls", func(t *testing.T) {
+		want := []string{"func x.Odd(n int) bool"}
+		if diff := cmp.Diff(want, calls(t, responses[3])); diff != "" {
+			t.Errorf("incomingCalls mismatch (-want +got):\n%s", diff)
+		}
+	})
+	t.Run("executeCommand", func(t *testing.T) {
+		var got string
+		if err := json.Unmarshal(responses[4].Result, &got); err != nil {
+			t.Fatalf("unexpected error: %+v", err)
+		}
+		want := `
+package github.com/podhmo/goinspect/internal/x
+
+@func x.RecRoot(n int)
+@@func x.Odd(n int) bool  // &25
+@@@func x.H()  // &5
+@@@func x.Even(n int) bool
+@@@@func x.H()  // *5
+@@@@func x.Odd(n int) bool  // *25 recursion`
+		if diff := cmp.Diff(strings.TrimSpace(want), strings.TrimSpace(got)); diff != "" {
+			t.Errorf("executeCommand mismatch (-want +got):\n%s", diff)
+		}
+	})
+	t.Run("unknown", func(t *testing.T) {
+		if responses[5].Error == nil || responses[5].Error.Code != -32601 {
+			t.Errorf("unknown method, want error -32601 but got %+v", responses[5].Error)
+		}
+	})
+	t.Run("malformed", func(t *testing.T) {
+		if responses[6].Error == nil || responses[6].Error.Code != -32700 {
+			t.Errorf("malformed request, want error -32700 but got %+v", responses[6].Error)
+		}
+		if responses[7].ID != 8 {
+			t.Errorf("the request after the malformed one, want id 8 but got %d", responses[7].ID)
+		}
+	})
+}
+
+func TestApplyCoverage(t *testing.T) {
+	pkg := "github.com/podhmo/goinspect/internal/x"
+	profile := `mode: set
+github.com/podhmo/goinspect/internal/x/func.go:10.13,14.2 3 1
+github.com/podhmo/goinspect/internal/x/func.go:15.11,18.2 2 0
+github.com/podhmo/goinspect/internal/x/func.go:20.11,24.2 3 0
+github.com/podhmo/goinspect/internal/x/func.go:38.10,40.2 1 1
+github.com/podhmo/goinspect/internal/x/func.go:15.11,18.2 2 1
+`
+	p, err := ParseCoverProfile(strings.NewReader(profile))
+	if err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+
+	testcases := []struct {
+		name          string
+		uncoveredOnly bool
+		want          string
+	}{
+		{
+			name: "cover",
+			want: `
+package github.com/podhmo/goinspect/internal/x
+
+@func x.F(s x.S)  cover:100.0%
+@@func x.F0()  cover:100.0%
+@@@func x.F1()  cover:0.0%
+@@@@func x.H()  cover:100.0%  // &5
+@@func x.H()  cover:100.0%  // *5`,
+		},
+		{
+			name:          "uncovered-only",
+			uncoveredOnly: true,
+			want: `
+package github.com/podhmo/goinspect/internal/x
+
+@func x.F(s x.S)  cover:100.0%
+@@func x.F0()  cover:100.0%
+@@@func x.F1()  cover:0.0%`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &Config{
+				Fset:          token.NewFileSet(),
+				PkgPath:       pkg,
+				Padding:       "@",
+				UncoveredOnly: tc.uncoveredOnly,
+			}
+			g := loadAndScan(t, c)
+			ApplyCoverage(c, g, p)
+
 			var nodes []*Node
 			g.Walk(func(n *Node) {
-				for _, name := range tc.names {
-					if name == n.Name {
-						nodes = append(nodes, n)
-					}
+				if n.Name == "F" {
+					nodes = append(nodes, n)
 				}
 			})
-
+			buf := new(bytes.Buffer)
 			if err := Dump(buf, c, g, nodes); err != nil {
-				t.Errorf("unexpected error: %+v", err)
+				t.Fatalf("unexpected error: %+v", err)
+			}
+			if diff := cmp.Diff(strings.TrimSpace(tc.want), strings.TrimSpace(buf.String())); diff != "" {
+				t.Errorf("Dump() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	pkg := "github.com/podhmo/goinspect/internal/x"
+
+	// the stacks (from the leaf) and the values
+	samples := []struct {
+		stack []string
+		value int64
+	}{
+		{stack: []string{"H", "F1", "F0", "F"}, value: 10},
+		{stack: []string{"F1", "F0", "F"}, value: 5},
+		{stack: []string{"log.func1", "F"}, value: 5},
+		{stack: []string{"H", "G"}, value: 5}, // not found statically (G -> G0 -> H)
+		{stack: []string{"H", "(*Handler).Serve"}, value: 5},
+	}
+	p := &profile.Profile{SampleType: []*profile.ValueType{{Type: "cpu", Unit: "nanoseconds"}}}
+	locations := map[string]*profile.Location{}
+	for _, s := range samples {
+		sample := &profile.Sample{Value: []int64{s.value}}
+		for _, name := range s.stack {
+			loc, ok := locations[name]
+			if !ok {
+				fn := &profile.Function{ID: uint64(len(p.Function) + 1), Name: pkg + "." + name}
+				loc = &profile.Location{ID: uint64(len(p.Location) + 1), Line: []profile.Line{{Function: fn}}}
+				p.Function = append(p.Function, fn)
+				p.Location = append(p.Location, loc)
+				locations[name] = loc
+			}
+			sample.Location = append(sample.Location, loc)
+		}
+		p.Sample = append(p.Sample, sample)
+	}
+	buf := new(bytes.Buffer)
+	if err := p.Write(buf); err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+	prof, err := LoadProfile(buf, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+
+	testcases := []struct {
+		name       string
+		minCum     float64
+		sortByCost bool
+		want       string
+	}{
+		{
+			name:       "min-cum",
+			minCum:     40,
+			sortByCost: true,
+			want: `
+package github.com/podhmo/goinspect/internal/x
+
+@func x.F(s x.S)  flat:0.0% cum:66.7%
+@@func x.H()  flat:66.7% cum:66.7%  // &5
+@@func x.F0()  flat:0.0% cum:50.0%
+@@@func x.F1()  flat:16.7% cum:50.0%
+@@@@func x.H()  flat:66.7% cum:66.7%  // *5`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &Config{
+				Fset:       token.NewFileSet(),
+				PkgPath:    pkg,
+				Padding:    "@",
+				MinCum:     tc.minCum,
+				SortByCost: tc.sortByCost,
+			}
+			g := loadAndScan(t, c)
+			report := ApplyProfile(c, g, prof)
+
+			buf := new(bytes.Buffer)
+			if err := DumpAll(buf, c, g); err != nil {
+				t.Fatalf("unexpected error: %+v", err)
+			}
+			if diff := cmp.Diff(strings.TrimSpace(tc.want), strings.TrimSpace(buf.String())); diff != "" {
+				t.Errorf("DumpAll() mismatch (-want +got):\n%s", diff)
+			}
+
+			buf.Reset()
+			if err := DumpProfileReport(buf, c, report); err != nil {
+				t.Fatalf("unexpected error: %+v", err)
+			}
+			want := `
+func x.G() -> func x.H()  16.7%
+# 5 of 6 edges in the profile (between the scanned functions) are found statically`
+			if diff := cmp.Diff(strings.TrimSpace(want), strings.TrimSpace(buf.String())); diff != "" {
+				t.Errorf("DumpProfileReport() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPprofName(t *testing.T) {
+	pkg := "github.com/podhmo/goinspect/internal/importname"
+	c := &Config{
+		Fset:    token.NewFileSet(),
+		PkgPath: pkg,
+		OtherPackages: []string{
+			"github.com/podhmo/goinspect/internal/importname/...",
+		},
+	}
+	g := loadAndScan(t, c)
+
+	got := map[string]string{}
+	g.Walk(func(n *Node) {
+		got[n.Value.ID] = pprofName(n)
+	})
+	want := map[string]string{
+		pkg + ".Run":             pkg + ".Run",
+		pkg + "/foo/v2.Foo":      pkg + "/foo/v2.Foo",
+		pkg + "/go-sqlite3.Open": pkg + "/go-sqlite3.Open",
+		pkg + "/yaml.v3.Marshal": pkg + "/yaml%2ev3.Marshal",
+		pkg + "/sub.Sub":         pkg + "/sub.Sub",
+		pkg + "/dot.Dot":         pkg + "/dot.Dot",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("pprofName() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTrackErrors(t *testing.T) {
+	pkg := "github.com/podhmo/goinspect/internal/errs"
+	c := &Config{
+		Fset:        token.NewFileSet(),
+		PkgPath:     pkg,
+		Padding:     "@",
+		TrackErrors: true,
+	}
+	g := loadAndScan(t, c)
+
+	want := `
+package github.com/podhmo/goinspect/internal/errs
+
+@func errs.Propagate() error
+@@func errs.Open() error  err:propagated  // &1
+@@func errs.Read() (int, error)  err:propagated  // &2
+
+@func errs.Wrap() error
+@@func errs.Read() (int, error)  err:wrapped  // *2
+
+@func errs.Named() (err error)
+@@func errs.Open() error  err:propagated  // *1
+
+@func errs.Handle()
+@@func errs.Open() error  err:handled  // *1
+@@func errs.Close()
+
+@func errs.Drop()
+@@func errs.Open() error  err:discarded,ignored  // *1
+@@func errs.Read() (int, error)  err:discarded,ignored  // *2`
+	buf := new(bytes.Buffer)
+	if err := DumpAll(buf, c, g); err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+	if diff := cmp.Diff(strings.TrimSpace(want), strings.TrimSpace(buf.String())); diff != "" {
+		t.Errorf("DumpAll() mismatch (-want +got):\n%s", diff)
+	}
+
+	t.Run("expand-all", func(t *testing.T) {
+		c := *c
+		c.ExpandAll = true
+		want := `
+package github.com/podhmo/goinspect/internal/errs
+
+@func errs.Propagate() error
+@@func errs.Open() error  err:propagated
+@@func errs.Read() (int, error)  err:propagated
+
+@func errs.Wrap() error
+@@func errs.Read() (int, error)  err:wrapped
+
+@func errs.Named() (err error)
+@@func errs.Open() error  err:propagated
+
+@func errs.Handle()
+@@func errs.Open() error  err:handled
+@@func errs.Close()
+
+@func errs.Drop()
+@@func errs.Open() error  err:discarded,ignored
+@@func errs.Read() (int, error)  err:discarded,ignored`
+		buf := new(bytes.Buffer)
+		if err := DumpAll(buf, &c, g); err != nil {
+			t.Fatalf("unexpected error: %+v", err)
+		}
+		if diff := cmp.Diff(strings.TrimSpace(want), strings.TrimSpace(buf.String())); diff != "" {
+			t.Errorf("DumpAll() mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestApplyEffects(t *testing.T) {
+	pkg := "github.com/podhmo/goinspect/internal/effects"
+	effects, err := ParseEffectCategories([]string{"io=os.*", "io=io.*", "db=database/sql.*", "net=net/http.Client.*", "time=time.Now"})
+	if err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+	c := &Config{
+		Fset:       token.NewFileSet(),
+		PkgPath:    pkg,
+		Padding:    "@",
+		Effects:    effects,
+		skipHeader: true,
+	}
+	g := loadAndScan(t, c)
+	ApplyEffects(c, g)
+
+	t.Run("tree", func(t *testing.T) {
+		want := `
+@func effects.Dump(s *effects.Store) error  [db,io,net,time]
+@@func (*effects.Store).Sync(url string) error  [db,io,net]
+@@@func (*effects.Store).Save(name string) error  [db]
+@@@func effects.Normalize(s string) string
+@@func effects.Stamp() string  [time]`
+		assertDump(t, c, g, []string{"Dump"}, want)
+	})
+
+	t.Run("witness", func(t *testing.T) {
+		var dump *Node
+		g.Walk(func(n *Node) {
+			if n.Name == "Dump" {
+				dump = n
 			}
+		})
+		effect := dump.Value.Effects["db"]
+		names := make([]string, len(effect.Path))
+		for i, x := range effect.Path {
+			names[i] = x.Name
+		}
+		if want, got := "Dump -> Sync -> Save -> database/sql.DB.Exec", strings.Join(append(names, effect.Call), " -> "); want != got {
+			t.Errorf("the witness of db, want %q but got %q", want, got)
+		}
+	})
+
+	t.Run("pure", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		if err := DumpPure(buf, c, g); err != nil {
+			t.Fatalf("unexpected error: %+v", err)
+		}
+		if want, got := "func effects.Normalize(s string) string", strings.TrimSpace(buf.String()); want != got {
+			t.Errorf("DumpPure() want %q but got %q", want, got)
+		}
+	})
+}
+
+func TestMetrics(t *testing.T) {
+	pkg := "github.com/podhmo/goinspect/internal/metrics"
+	c := &Config{
+		Fset:              token.NewFileSet(),
+		PkgPath:           pkg,
+		Padding:           "@",
+		IncludeUnexported: true,
+		ShowMetrics:       true,
+		skipHeader:        true,
+	}
+	g := loadAndScan(t, c)
 
+	want := `
+@func metrics.Run(xs []int) int  loc:4 cc:1 params:1 results:1 depth:0  total(loc:27 cc:10)
+@@func metrics.Classify(xs []int, strict bool) (pos int, neg int)  loc:17 cc:7 params:2 results:2 depth:2  total(loc:23 cc:9)
+@@@func metrics.clamp(n int) int  loc:6 cc:2 params:1 results:1 depth:2
+
+@func metrics.Count(xss [][]int) int  loc:18 cc:7 params:1 results:1 depth:3`
+	t.Run("tree", func(t *testing.T) {
+		assertDump(t, c, g, []string{"Run", "Count"}, want)
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		want := `
+functions: 4
+edges: 2
+roots: 2
+lines: 45
+complexity: 17
+complexity (avg): 4.2
+depth (max): 3
+
+# the most complex functions
+func metrics.Count(xss [][]int) int  loc:18 cc:7 params:1 results:1 depth:3  total(loc:18 cc:7)
+func metrics.Classify(xs []int, strict bool) (pos int, neg int)  loc:17 cc:7 params:2 results:2 depth:2  total(loc:23 cc:9)
+func metrics.clamp(n int) int  loc:6 cc:2 params:1 results:1 depth:2  total(loc:6 cc:2)
+func metrics.Run(xs []int) int  loc:4 cc:1 params:1 results:1 depth:0  total(loc:27 cc:10)`
+		buf := new(bytes.Buffer)
+		if err := DumpStats(buf, c, g); err != nil {
+			t.Fatalf("unexpected error: %+v", err)
+		}
+		if diff := cmp.Diff(strings.TrimSpace(want), strings.TrimSpace(buf.String())); diff != "" {
+			t.Errorf("DumpStats() mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestApplyTerminations(t *testing.T) {
+	pkg := "github.com/podhmo/goinspect/internal/exits"
+
+	testcases := []struct {
+		name     string
+		patterns []string
+		want     string
+	}{
+		{
+			name: "all",
+			want: `
+func exits.Check(v int) -> panic
+func exits.Fail() -> log.Logger#Panicln
+func exits.Load() -> func exits.Check(v int) -> panic
+func exits.Main() -> os.Exit
+func exits.Must(err error) -> log.Fatalf
+func exits.Setup() -> func exits.Must(err error) -> log.Fatalf`,
+		},
+		{
+			name:     "exit",
+			patterns: []string{"os.Exit", "log.Fatal*"},
+			want: `
+func exits.Main() -> os.Exit
+func exits.Must(err error) -> log.Fatalf
+func exits.Setup() -> func exits.Must(err error) -> log.Fatalf`,
+		},
+	}
+
+	c := &Config{
+		Fset:              token.NewFileSet(),
+		PkgPath:           pkg,
+		Padding:           "@",
+		TrackTerminations: true,
+	}
+	g := loadAndScan(t, c)
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ApplyTerminations(g, tc.patterns)
+			buf := new(bytes.Buffer)
+			if err := DumpTerminations(buf, c, g); err != nil {
+				t.Fatalf("unexpected error: %+v", err)
+			}
 			if diff := cmp.Diff(strings.TrimSpace(tc.want), strings.TrimSpace(buf.String())); diff != "" {
-				t.Errorf("Scan() mismatch (-want +got):\n%s", diff)
+				t.Errorf("DumpTerminations() mismatch (-want +got):\n%s", diff)
 			}
 		})
 	}
+
+	t.Run("tree", func(t *testing.T) {
+		ApplyTerminations(g, []string{"os.Exit", "log.Fatal*"})
+		want := `
+package github.com/podhmo/goinspect/internal/exits
+
+@func exits.Main()  !calls:os.Exit
+@@func exits.Setup()  !reaches:log.Fatalf
+@@@func exits.Must(err error)  !calls:log.Fatalf
+@@@func exits.Load()
+@@@@func exits.Check(v int)
+
+@func exits.Fail()
+
+@func exits.Safe() int`
+		buf := new(bytes.Buffer)
+		if err := DumpAll(buf, c, g); err != nil {
+			t.Fatalf("unexpected error: %+v", err)
+		}
+		if diff := cmp.Diff(strings.TrimSpace(want), strings.TrimSpace(buf.String())); diff != "" {
+			t.Errorf("DumpAll() mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestContextFindings(t *testing.T) {
+	pkg := "github.com/podhmo/goinspect/internal/ctxs"
+	c := &Config{
+		Fset:          token.NewFileSet(),
+		PkgPath:       pkg,
+		TrackContexts: true,
+	}
+	g := loadAndScan(t, c)
+
+	want := `
+orphaned: func ctxs.Main() -> func ctxs.Serve(ctx context.Context)
+orphaned: func ctxs.Tick() -> func (*ctxs.Worker).Do(ctx context.Context)
+replaced: func (*ctxs.Worker).Do(ctx context.Context) -> func ctxs.Notify(ctx context.Context)  context.Background()
+replaced: func ctxs.Handle(ctx context.Context) -> func ctxs.Query(ctx context.Context, q string)  context.TODO()`
+	buf := new(bytes.Buffer)
+	if err := DumpContextFindings(buf, c, ContextFindings(g)); err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+	if diff := cmp.Diff(strings.TrimSpace(want), strings.TrimSpace(buf.String())); diff != "" {
+		t.Errorf("DumpContextFindings() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLockFindings(t *testing.T) {
+	pkg := "github.com/podhmo/goinspect/internal/locks"
+	c := &Config{
+		Fset:       token.NewFileSet(),
+		PkgPath:    pkg,
+		TrackLocks: true,
+	}
+	g := loadAndScan(t, c)
+
+	want := `
+inversion: locks.Cache.mu <-> locks.Store.Mutex
+order: locks.Cache.mu -> locks.Store.Mutex  func (*locks.Cache).Flush() -> func (*locks.Store).Save(k string, v string)
+order: locks.Cache.mu -> locks.registryMu  func (*locks.Cache).Flush() -> func (*locks.Store).Save(k string, v string) -> func locks.register(k string)
+order: locks.Store.Mutex -> locks.Cache.mu  func (*locks.Store).Reload() -> func (*locks.Cache).Flush()
+order: locks.Store.Mutex -> locks.registryMu  func (*locks.Store).Save(k string, v string) -> func locks.register(k string)
+self-deadlock: locks.Cache.mu  func (*locks.Cache).Get(k string) string -> func (*locks.Cache).load(k string) string
+self-deadlock: locks.Store.Mutex  func (*locks.Store).Reload() -> func (*locks.Cache).Flush() -> func (*locks.Store).Save(k string, v string)`
+	buf := new(bytes.Buffer)
+	if err := DumpLockFindings(buf, c, LockFindings(g)); err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+	if diff := cmp.Diff(strings.TrimSpace(want), strings.TrimSpace(buf.String())); diff != "" {
+		t.Errorf("DumpLockFindings() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCommMap(t *testing.T) {
+	pkg := "github.com/podhmo/goinspect/internal/chans"
+	c := &Config{
+		Fset:      token.NewFileSet(),
+		PkgPath:   pkg,
+		TrackComm: true,
+	}
+	g := loadAndScan(t, c)
+
+	want := `
+func chans.Stop();
+go func chans.notify();
+func chans.Stop() -> go func chans.notify();
+chan chans.done;
+go func chans.notify() -> chan chans.done;
+func chans.Wait();
+chan chans.done -> func chans.Wait();
+func (*chans.Pipeline).Run(xs []int) []int;
+go func (*chans.Pipeline).work();
+func (*chans.Pipeline).Run(xs []int) []int -> go func (*chans.Pipeline).work();
+chan chans.Pipeline.results;
+go func (*chans.Pipeline).work() -> chan chans.Pipeline.results;
+chan chans.Pipeline.results -> func (*chans.Pipeline).Run(xs []int) []int;
+go func (*chans.Pipeline).feed(xs []int);
+func (*chans.Pipeline).Run(xs []int) []int -> go func (*chans.Pipeline).feed(xs []int);
+chan chans.Pipeline.jobs;
+go func (*chans.Pipeline).feed(xs []int) -> chan chans.Pipeline.jobs;
+chan chans.Pipeline.jobs -> go func (*chans.Pipeline).work();`
+	buf := new(bytes.Buffer)
+	if err := graph.RenderText(buf, CommMap(c, g)); err != nil {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+	if diff := cmp.Diff(strings.TrimSpace(want), strings.TrimSpace(buf.String())); diff != "" {
+		t.Errorf("CommMap() mismatch (-want +got):\n%s", diff)
+	}
 }
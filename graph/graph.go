@@ -149,11 +149,32 @@ func (g *Graph[K, T]) WalkPath(fn func([]*Node[T]), nodes []*Node[T]) {
 		nodes = g.Nodes
 	}
 
-	seen := map[key]struct{}{}
+	roots := make([]*Node[T], 0, len(nodes))
 	for _, n := range nodes {
 		if len(n.From) > 0 {
 			continue
 		}
+		roots = append(roots, n)
+	}
+	seen := map[key]struct{}{}
+	g.walkPath(fn, roots, true, seen)
+
+	// the nodes only in cycles (not reachable from the roots)
+	for _, n := range nodes {
+		if _, ok := seen[key{prev: n.ID}]; !ok {
+			g.walkPath(fn, []*Node[T]{n}, true, seen)
+		}
+	}
+}
+
+// WalkPathFrom is like WalkPath, but walks from roots even if they have incoming edges.
+// Unlike WalkPath, the nodes reached from roots are not passed to fn as single-node paths.
+func (g *Graph[K, T]) WalkPathFrom(fn func([]*Node[T]), roots []*Node[T]) {
+	g.walkPath(fn, roots, false, map[key]struct{}{})
+}
+
+func (g *Graph[K, T]) walkPath(fn func([]*Node[T]), roots []*Node[T], declare bool, seen map[key]struct{}) {
+	for _, n := range roots {
 		k := key{prev: n.ID}
 		if _, ok := seen[k]; ok {
 			continue
@@ -177,7 +198,7 @@ func (g *Graph[K, T]) WalkPath(fn func([]*Node[T]), nodes []*Node[T]) {
 					continue
 				}
 
-				{
+				if declare {
 					k := key{prev: next.ID}
 					if _, ok := seen[k]; !ok {
 						seen[k] = struct{}{}
@@ -54,3 +54,31 @@ func TestGraphWalk(t *testing.T) {
 		})
 	}
 }
+
+func TestGraphWalkPathFrom(t *testing.T) {
+	type ref struct{ Xs [][]int }
+
+	// 1 -> 2 -> 3, 4 -> 3
+	g := Ints()
+	n1 := g.Madd(1)
+	n2 := g.Madd(2)
+	n3 := g.Madd(3)
+	n4 := g.Madd(4)
+	g.LinkTo(n1, n2)
+	g.LinkTo(n2, n3)
+	g.LinkTo(n4, n3)
+
+	var got [][]int
+	g.WalkPathFrom(func(path []*Node[int]) {
+		xs := make([]int, len(path))
+		for i, n := range path {
+			xs[i] = n.Value
+		}
+		got = append(got, xs)
+	}, []*Node[int]{n2, n4})
+
+	want := [][]int{{2}, {2, 3}, {4}, {4, 3}}
+	if diff := cmp.Diff(ref{want}, ref{got}); diff != "" {
+		t.Errorf("WalkPathFrom() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -70,6 +70,18 @@ func TestRenderText(t *testing.T) {
 				return g
 			}(),
 		},
+		{msg: "cycle", want: "1;\n2;\n1 -> 2;\n2 -> 1;",
+			g: func() *Graph[int, int] {
+				g := Ints()
+
+				n1 := g.Madd(1)
+				n2 := g.Madd(2)
+
+				g.LinkTo(n1, n2)
+				g.LinkTo(n2, n1)
+				return g
+			}(),
+		},
 	}
 
 	for _, c := range cases {
@@ -88,3 +100,20 @@ func TestRenderText(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderMermaid(t *testing.T) {
+	g := Strings()
+	start := g.Madd("F()")
+	isIt := g.Madd(`is "it"?`)
+	isIt.Metadata.Shape = ShapeRhombus
+	g.LinkTo(start, isIt)
+
+	want := "```mermaid\nflowchart TB\n\tG1[\"F()\"];\n\tG2{\"is #quot;it#quot;?\"};\n\tG1 --> G2\n```"
+	buf := new(bytes.Buffer)
+	if err := RenderMermaid(buf, g); err != nil {
+		t.Errorf("RenderMermaid(), unexpected error: %+v", err)
+	}
+	if diff := cmp.Diff(want, strings.TrimSpace(buf.String())); diff != "" {
+		t.Errorf("RenderMermaid() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -3,6 +3,7 @@ package graph
 import (
 	"fmt"
 	"io"
+	"strings"
 )
 
 type RenderFunc[K comparable, T any] func(io.Writer, *Graph[K, T]) error
@@ -28,9 +29,9 @@ func RenderMermaid[K comparable, T any](w io.Writer, g *Graph[K, T]) error {
 			n := path[0]
 			switch n.Metadata.Shape {
 			case ShapeRhombus:
-				fmt.Fprintf(w, "\tG%d{%v};\n", n.ID, n.Value)
+				fmt.Fprintf(w, "\tG%d{%s};\n", n.ID, mermaidLabel(n.Value))
 			default:
-				fmt.Fprintf(w, "\tG%d[%v];\n", n.ID, n.Value)
+				fmt.Fprintf(w, "\tG%d[%s];\n", n.ID, mermaidLabel(n.Value))
 			}
 		} else {
 			n, next := path[len(path)-2], path[len(path)-1]
@@ -41,6 +42,11 @@ func RenderMermaid[K comparable, T any](w io.Writer, g *Graph[K, T]) error {
 	return nil
 }
 
+// mermaidLabel returns the quoted label, the symbols (e.g. "()", "[]") are not parsed as the shapes.
+func mermaidLabel(v any) string {
+	return `"` + strings.ReplaceAll(fmt.Sprint(v), `"`, "#quot;") + `"`
+}
+
 type Shape string
 
 const (
@@ -0,0 +1,235 @@
+package goinspect
+
+import (
+	"fmt"
+	"go/ast"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type htmlOutput struct {
+	Title    string
+	Sections []htmlSection
+}
+
+type htmlSection struct {
+	Title string
+	Nodes []*htmlNode
+}
+
+type htmlNode struct {
+	Name   string
+	Text   string
+	Anchor string // the first row of the node has the anchor, linked from the other rows
+	Ref    string // "&N", "*N" or "*N recursion", if the node is shown multiple times
+	Href   string // the anchor of the first row, if Ref is "*N"
+	Pos    string // <basename>:<line>
+	Source string // the source of the declaration, only in the first row
+
+	Uncovered bool // the coverage is 0%, if ApplyCoverage
+
+	Callers  []htmlLink // only in the first row
+	Children []*htmlNode
+}
+
+type htmlLink struct {
+	Text string
+	Href string
+}
+
+// DumpAllHTML dumps all nodes of the graph as one merged forest, into a self-contained HTML file.
+func DumpAllHTML(w io.Writer, c *Config, g *Graph) error {
+	rows, sameIDRows := collectRows(c, g, g.Nodes, nil, isRoot)
+	section := htmlSection{Title: strings.Join(c.Targets(), ", ")}
+	return dumpHTML(w, c, g, []htmlSection{section}, [][]*row{rows}, []map[int][]*row{sameIDRows})
+}
+
+// DumpPackagesHTML dumps the nodes of each target package as a section, into a self-contained HTML file.
+func DumpPackagesHTML(w io.Writer, c *Config, g *Graph) error {
+	targets := c.Targets()
+	sections := make([]htmlSection, len(targets))
+	rowsList := make([][]*row, len(targets))
+	sameIDRowsList := make([]map[int][]*row, len(targets))
+	for i, pkgpath := range targets {
+		copied := *c
+		copied.PkgPath = pkgpath
+		copied.PkgPaths = nil
+		sections[i] = htmlSection{Title: pkgpath}
+		rowsList[i], sameIDRowsList[i] = collectRows(&copied, g, g.Nodes, nil, packageRoot(pkgpath))
+	}
+	return dumpHTML(w, c, g, sections, rowsList, sameIDRowsList)
+}
+
+// DumpHTML dumps the nodes related to nodes (the callers and the callees, recursively), into a self-contained HTML file.
+func DumpHTML(w io.Writer, c *Config, g *Graph, nodes []*Node) error {
+	selected, seen := selectNodes(g, nodes)
+	rows, sameIDRows := collectRows(c, g, selected, seen, isRoot)
+	section := htmlSection{Title: strings.Join(c.Targets(), ", ")}
+	return dumpHTML(w, c, g, []htmlSection{section}, [][]*row{rows}, []map[int][]*row{sameIDRows})
+}
+
+func dumpHTML(w io.Writer, c *Config, g *Graph, sections []htmlSection, rowsList [][]*row, sameIDRowsList []map[int][]*row) error {
+	nodes := make(map[int]*Node, len(g.Nodes))
+	for _, n := range g.Nodes {
+		nodes[n.ID] = n
+	}
+	sources := newSourceCache()
+	prefix := textPrefix(c.PkgPath)
+
+	for i := range sections {
+		rows, sameIDRows := rowsList[i], sameIDRowsList[i]
+		anchor := func(id int) string { return fmt.Sprintf("s%d-n%d", i, id) }
+
+		// the rows are nested by indent
+		var stack []*htmlNode // stack[k] is the last node at indent k+1
+		for _, row := range rows {
+			n := nodes[row.id]
+			hn := &htmlNode{Name: row.name, Text: rowText(row), Uncovered: row.cover != nil && row.cover.Covered == 0}
+			if same := sameIDRows[row.id]; same[0] == row {
+				hn.Anchor = anchor(row.id)
+				if len(same) > 1 {
+					hn.Ref = fmt.Sprintf("&%d", row.id)
+				}
+				hn.Source = sources.text(c, n.Value.Decl)
+				for _, prev := range n.From {
+					if _, ok := sameIDRows[prev.ID]; ok {
+						hn.Callers = append(hn.Callers, htmlLink{Text: nodeText(c, prev, prefix), Href: anchor(prev.ID)})
+					}
+				}
+			} else if row.isRecursive {
+				hn.Ref = fmt.Sprintf("*%d recursion", row.id)
+				hn.Href = anchor(row.id)
+			} else {
+				hn.Ref = fmt.Sprintf("*%d", row.id)
+				hn.Href = anchor(row.id)
+			}
+			if pos := n.Value.Object.Pos(); pos.IsValid() && c.Fset != nil {
+				position := c.Fset.Position(pos)
+				hn.Pos = fmt.Sprintf("%s:%d", filepath.Base(position.Filename), position.Line)
+			}
+
+			depth := row.indent - 1
+			if depth > len(stack) {
+				depth = len(stack)
+			}
+			stack = stack[:depth]
+			if depth == 0 {
+				sections[i].Nodes = append(sections[i].Nodes, hn)
+			} else {
+				parent := stack[depth-1]
+				parent.Children = append(parent.Children, hn)
+			}
+			stack = append(stack, hn)
+		}
+	}
+
+	out := htmlOutput{Title: strings.Join(c.Targets(), ", "), Sections: sections}
+	return htmlTemplate.Execute(w, out)
+}
+
+// sourceCache reads the source of declarations, caching the files. It is not safe for concurrent use.
+type sourceCache struct {
+	files map[string][]byte
+}
+
+func newSourceCache() *sourceCache {
+	return &sourceCache{files: map[string][]byte{}}
+}
+
+func (s *sourceCache) text(c *Config, decl ast.Node) string {
+	if decl == nil || c.Fset == nil {
+		return ""
+	}
+	start, end := c.Fset.Position(decl.Pos()), c.Fset.Position(decl.End())
+	b, ok := s.files[start.Filename]
+	if !ok {
+		b, _ = os.ReadFile(start.Filename) // if not found, the source is omitted
+		s.files[start.Filename] = b
+	}
+	if end.Offset > len(b) || start.Offset > end.Offset {
+		return ""
+	}
+	text := string(b[start.Offset:end.Offset])
+	if _, ok := decl.(*ast.TypeSpec); ok {
+		text = "type " + text
+	}
+	return text
+}
+
+var htmlTemplate = template.Must(template.New("html").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>goinspect {{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 1em 2em; }
+.tree { font-family: monospace; }
+.tree details, .tree .leaf { margin-left: 1.5em; }
+.tree > details, .tree > .leaf { margin-left: 0; }
+.leaf { padding-left: 1.1em; }
+summary { cursor: pointer; }
+.ref, .pos, .callers { color: #888; }
+.pos, .callers { font-size: smaller; }
+.callers { margin-left: 1.5em; }
+.hit > summary > .text, .hit > .text { background: #ff0; }
+.uncovered { color: #c00; }
+:target > summary, .leaf:target { outline: 2px solid #36c; }
+pre.src { background: #f6f6f6; margin: .2em 0 .2em 1.5em; padding: .5em; max-height: 20em; overflow: auto; }
+</style>
+</head>
+<body>
+<h1>goinspect</h1>
+<input id="search" type="search" placeholder="search by name" autofocus> <span id="count"></span>
+{{- range .Sections}}
+<h2>package {{.Title}}</h2>
+<div class="tree">
+{{- range .Nodes}}
+{{template "node" .}}
+{{- end}}
+</div>
+{{- end}}
+<script>
+function reveal(el) {
+  for (var p = el.parentElement; p; p = p.parentElement) {
+    if (p.tagName === "DETAILS") { p.open = true; }
+  }
+}
+function jump() {
+  var el = document.getElementById(location.hash.slice(1));
+  if (el) { reveal(el); el.scrollIntoView(); }
+}
+window.addEventListener("hashchange", jump);
+jump();
+document.getElementById("search").addEventListener("input", function (ev) {
+  var q = ev.target.value.toLowerCase(), n = 0;
+  document.querySelectorAll("[data-name]").forEach(function (el) {
+    var hit = q !== "" && el.dataset.name.toLowerCase().indexOf(q) >= 0;
+    el.classList.toggle("hit", hit);
+    if (hit) { reveal(el); n++; }
+  });
+  document.getElementById("count").textContent = q === "" ? "" : n + " found";
+});
+</script>
+</body>
+</html>
+{{define "node" -}}
+{{if or .Children .Source .Callers -}}
+<details{{if .Anchor}} id="{{.Anchor}}"{{end}} data-name="{{.Name}}"><summary>{{template "label" .}}</summary>
+{{- if .Source}}<pre class="src">{{.Source}}</pre>{{end}}
+{{- if .Callers}}<div class="callers">called by {{range $i, $x := .Callers}}{{if $i}}, {{end}}<a href="#{{$x.Href}}">{{$x.Text}}</a>{{end}}</div>{{end}}
+{{- range .Children}}
+{{template "node" .}}
+{{- end}}
+</details>
+{{- else -}}
+<div class="leaf"{{if .Anchor}} id="{{.Anchor}}"{{end}} data-name="{{.Name}}">{{template "label" .}}</div>
+{{- end}}
+{{- end}}
+{{define "label"}}<span class="text{{if .Uncovered}} uncovered{{end}}">{{.Text}}</span>
+{{- if .Ref}} <span class="ref">// {{if .Href}}<a href="#{{.Href}}">{{.Ref}}</a>{{else}}{{.Ref}}{{end}}</span>{{end}}
+{{- if .Pos}} <span class="pos">{{.Pos}}</span>{{end}}
+{{- end}}
+`))
@@ -0,0 +1,8 @@
+package buildtags
+
+func Run() {
+	Platform()
+	Common()
+}
+
+func Common() {}
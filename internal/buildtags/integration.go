@@ -0,0 +1,7 @@
+//go:build integration
+
+package buildtags
+
+func Integration() {
+	Run()
+}
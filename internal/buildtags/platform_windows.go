@@ -0,0 +1,7 @@
+package buildtags
+
+func Platform() {
+	Windows()
+}
+
+func Windows() {}
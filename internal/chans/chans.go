@@ -0,0 +1,52 @@
+package chans
+
+var done = make(chan struct{})
+
+type Pipeline struct {
+	jobs    chan int
+	results chan int
+}
+
+func (p *Pipeline) Run(xs []int) []int {
+	for i := 0; i < 2; i++ {
+		go p.work()
+	}
+	go func() {
+		p.feed(xs)
+	}()
+
+	var r []int
+	for range xs {
+		r = append(r, <-p.results)
+	}
+	return r
+}
+
+func (p *Pipeline) feed(xs []int) {
+	for _, x := range xs {
+		p.jobs <- x
+	}
+	close(p.jobs)
+}
+
+func (p *Pipeline) work() {
+	for x := range p.jobs {
+		p.results <- square(x)
+	}
+}
+
+func square(x int) int {
+	return x * x
+}
+
+func Stop() {
+	go notify()
+}
+
+func notify() {
+	done <- struct{}{}
+}
+
+func Wait() {
+	<-done
+}
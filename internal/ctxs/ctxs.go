@@ -0,0 +1,35 @@
+package ctxs
+
+import "context"
+
+func Main() {
+	Run()
+	Serve(context.Background())
+}
+
+func Run() {
+	Query(context.Background(), "run")
+}
+
+func Serve(ctx context.Context) {
+	Handle(ctx)
+}
+
+func Handle(ctx context.Context) {
+	Query(ctx, "ok")
+	Query(context.TODO(), "todo")
+}
+
+func Query(ctx context.Context, q string) {}
+
+type Worker struct{}
+
+func (w *Worker) Do(ctx context.Context) {
+	Notify(context.Background())
+}
+
+func Tick() {
+	new(Worker).Do(context.Background())
+}
+
+func Notify(ctx context.Context) {}
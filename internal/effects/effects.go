@@ -0,0 +1,43 @@
+package effects
+
+import (
+	"database/sql"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+type Store struct {
+	db     *sql.DB
+	client *http.Client
+}
+
+func (s *Store) Save(name string) error {
+	_, err := s.db.Exec("INSERT INTO files (name) VALUES (?)", name)
+	return err
+}
+
+func (s *Store) Sync(url string) error {
+	resp, err := s.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return s.Save(Normalize(url))
+}
+
+func Normalize(s string) string {
+	return strings.ToLower(s)
+}
+
+func Stamp() string {
+	return time.Now().Format(time.RFC3339)
+}
+
+func Dump(s *Store) error {
+	if err := s.Sync(Stamp()); err != nil {
+		return err
+	}
+	return os.WriteFile("dump", nil, 0644)
+}
@@ -0,0 +1,54 @@
+package entries
+
+import (
+	"net/http"
+)
+
+// Command is a CLI command, registered with the Run field.
+type Command struct {
+	Name string
+	Run  func(args []string) error
+}
+
+func Main() {
+	http.HandleFunc("/", index)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users", (&users{}).list)
+	mux.Handle("/health", http.HandlerFunc(health))
+	mux.Handle("/api", &api{})
+
+	cmd := &Command{Name: "serve", Run: serve}
+	cmd.Run = migrate
+}
+
+func index(w http.ResponseWriter, r *http.Request) {
+	Render(w)
+}
+
+type users struct{}
+
+func (u *users) list(w http.ResponseWriter, r *http.Request) {
+	Render(w)
+}
+
+func health(w http.ResponseWriter, r *http.Request) {}
+
+type api struct{}
+
+func (a *api) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	Render(w)
+	a.serve() // hidden, not the entry point of the same name (serve)
+}
+
+func (a *api) serve() {}
+
+func Render(w http.ResponseWriter) {}
+
+func serve(args []string) error {
+	return nil
+}
+
+func migrate(args []string) error {
+	return nil
+}
@@ -0,0 +1,53 @@
+package errs
+
+import (
+	"fmt"
+	"log"
+)
+
+func Open() error { return nil }
+
+func Read() (int, error) { return 0, nil }
+
+func Close() {}
+
+func Propagate() error {
+	if err := Open(); err != nil {
+		return err
+	}
+	n, err := Read()
+	if err != nil {
+		return err
+	}
+	_ = n
+	return Open()
+}
+
+func Wrap() error {
+	if _, err := Read(); err != nil {
+		return fmt.Errorf("read: %w", err)
+	}
+	return nil
+}
+
+func Named() (err error) {
+	err = Open()
+	return
+}
+
+func Handle() {
+	if err := Open(); err != nil {
+		log.Println(err)
+	}
+	Close()
+}
+
+func Drop() {
+	_ = Open()
+	_, _ = Read()
+	Open()
+	defer Open()
+	go func() {
+		Read()
+	}()
+}
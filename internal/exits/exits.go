@@ -0,0 +1,42 @@
+package exits
+
+import (
+	"log"
+	"os"
+)
+
+var logger = log.New(os.Stderr, "", 0)
+
+func Main() {
+	Setup()
+	os.Exit(0)
+}
+
+func Setup() {
+	Must(nil)
+	Load()
+}
+
+func Must(err error) {
+	if err != nil {
+		log.Fatalf("!! %+v", err)
+	}
+}
+
+func Load() {
+	Check(1)
+}
+
+func Check(v int) {
+	if v < 0 {
+		panic("negative")
+	}
+}
+
+func Fail() {
+	logger.Panicln("fail")
+}
+
+func Safe() int {
+	return len("safe") // builtins other than panic are not terminating
+}
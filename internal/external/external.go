@@ -0,0 +1,23 @@
+package external
+
+import (
+	"database/sql"
+	"net/http"
+	"os"
+	"strings"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func (s *Store) Save(name string, data []byte) error {
+	if _, err := s.db.Exec("INSERT INTO files (name) VALUES (?)", name); err != nil {
+		return err
+	}
+	return os.WriteFile(strings.ToLower(name), data, 0644)
+}
+
+func Fetch(url string) (*http.Response, error) {
+	return http.Get(url)
+}
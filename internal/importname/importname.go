@@ -0,0 +1,18 @@
+package importname
+
+import (
+	"github.com/podhmo/goinspect/internal/importname/foo/v2"
+	"github.com/podhmo/goinspect/internal/importname/go-sqlite3"
+	renamed "github.com/podhmo/goinspect/internal/importname/sub"
+	"github.com/podhmo/goinspect/internal/importname/yaml.v3"
+
+	. "github.com/podhmo/goinspect/internal/importname/dot"
+)
+
+func Run() {
+	foo.Foo()
+	sqlite3.Open()
+	yaml.Marshal()
+	renamed.Sub()
+	Dot()
+}
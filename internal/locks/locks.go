@@ -0,0 +1,80 @@
+package locks
+
+import "sync"
+
+var registryMu sync.Mutex
+
+type Cache struct {
+	mu    sync.RWMutex
+	store *Store
+	items map[string]string
+}
+
+func (c *Cache) Get(k string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.load(k)
+}
+
+func (c *Cache) load(k string) string {
+	c.mu.RLock() // self-deadlock, if a writer is waiting
+	defer c.mu.RUnlock()
+	return c.items[k]
+}
+
+func (c *Cache) Put(k, v string) {
+	c.mu.Lock()
+	c.items[k] = v
+	c.mu.Unlock()
+	c.store.Save(k, v) // after Unlock
+}
+
+func (c *Cache) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, v := range c.items {
+		c.store.Save(k, v)
+	}
+}
+
+type Store struct {
+	sync.Mutex
+	cache *Cache
+}
+
+func (s *Store) Save(k, v string) {
+	s.Lock()
+	defer s.Unlock()
+	register(k)
+}
+
+func (s *Store) Reload() {
+	s.Lock()
+	defer s.Unlock()
+	go func() {
+		s.cache.Put("k", "v") // in another goroutine
+	}()
+	s.cache.Flush()
+}
+
+func register(k string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+}
+
+type Server struct {
+	mu      sync.Mutex
+	running int
+}
+
+func (s *Server) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	go s.worker() // the goroutine does not hold s.mu
+}
+
+func (s *Server) worker() {
+	s.mu.Lock()
+	s.running++
+	s.mu.Unlock()
+}
@@ -0,0 +1,50 @@
+package metrics
+
+func Run(xs []int) int {
+	pos, neg := Classify(xs, true)
+	return pos - neg
+}
+
+func Classify(xs []int, strict bool) (pos, neg int) {
+	for _, x := range xs {
+		switch {
+		case x > 0 && strict:
+			pos++
+		case x < 0:
+			neg++
+		default:
+		}
+	}
+	if pos > 0 {
+		pos = clamp(pos)
+	} else if neg > 0 {
+		neg = clamp(neg)
+	}
+	return pos, neg
+}
+
+func clamp(n int) int {
+	if func() bool { return n > 10 }() {
+		return 10
+	}
+	return n
+}
+
+func Count(xss [][]int) int {
+	n := 0
+	if xss == nil {
+		n = -1
+	} else if len(xss) == 0 {
+		n = 0
+	} else if len(xss[0]) == 0 {
+		n = 1
+	}
+	for _, xs := range xss {
+		for _, x := range xs {
+			if x > 0 {
+				n++
+			}
+		}
+	}
+	return n
+}
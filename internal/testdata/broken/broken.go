@@ -0,0 +1,26 @@
+package broken
+
+import "github.com/podhmo/goinspect/internal/testdata/broken/sub"
+
+func F() {
+	G()
+	H(undefined)
+	sub.X()
+	sub.Undefined() // e.g. renamed in the middle of refactoring
+}
+
+func G() {}
+
+func H(n int) {}
+
+func I() int {
+	return "I"
+}
+
+type T struct {
+	Value Undefined
+}
+
+func (t *T) M() {
+	G()
+}
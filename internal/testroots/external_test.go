@@ -0,0 +1,13 @@
+package testroots_test
+
+import (
+	"testing"
+
+	"github.com/podhmo/goinspect/internal/testroots"
+)
+
+func FuzzR(f *testing.F) {
+	f.Fuzz(func(t *testing.T, n int) {
+		testroots.R(n % 10)
+	})
+}
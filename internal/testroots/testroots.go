@@ -0,0 +1,27 @@
+package testroots
+
+func F0() {
+	F1()
+}
+
+func F1() {
+	H()
+}
+
+func G() {
+	H()
+}
+
+func H() {}
+
+func R(n int) int {
+	if n <= 0 {
+		H()
+		return 1
+	}
+	return R(n-1) * n
+}
+
+func RecRoot(n int) {
+	R(n)
+}
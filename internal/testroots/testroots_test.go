@@ -0,0 +1,13 @@
+package testroots
+
+import "testing"
+
+func TestF0(t *testing.T) {
+	F0()
+}
+
+func BenchmarkG(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		G()
+	}
+}
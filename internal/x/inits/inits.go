@@ -0,0 +1,44 @@
+package inits
+
+import "github.com/podhmo/goinspect/internal/x/sub"
+
+var DefaultClient = NewClient()
+
+var (
+	name           = "inits"
+	state0, state1 = newState(), newState()
+)
+
+func init() {
+	Setup()
+}
+
+func init() {
+	DefaultClient.Register(name)
+}
+
+type Client struct{}
+
+func NewClient() *Client {
+	c := &Client{}
+	c.init()
+	return c
+}
+
+func (c *Client) init() {}
+
+func (c *Client) Register(name string) {
+	sub.X()
+}
+
+func Setup() {
+	Register(name)
+}
+
+func Register(name string) {}
+
+type state struct{}
+
+func newState() *state {
+	return &state{}
+}
@@ -0,0 +1,23 @@
+package x
+
+import "github.com/podhmo/goinspect/internal/x/sub"
+
+type Handler struct{}
+
+func (h *Handler) Serve(s S) {
+	H()
+}
+
+type Hooks struct {
+	OnStart func()
+}
+
+func Handle(fn func(S)) {}
+
+func Register() {
+	h := &Handler{}
+	Handle(h.Serve)
+	Handle(F)
+	_ = Hooks{OnStart: sub.X}
+	_ = map[string]func(){"g": G0}
+}
@@ -0,0 +1,183 @@
+package goinspect
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+type jsonOutput struct {
+	Package  string     `json:"package"`
+	Packages []string   `json:"packages,omitempty"` // if multiple target packages
+	Builds   []string   `json:"builds,omitempty"`
+	Nodes    []jsonNode `json:"nodes"`
+}
+
+type jsonNode struct {
+	ID         int          `json:"id"`
+	Key        string       `json:"key"` // Subject.ID
+	Name       string       `json:"name"`
+	Recv       string       `json:"recv,omitempty"`
+	Kind       Kind         `json:"kind"`
+	Text       string       `json:"text"`
+	Pos        string       `json:"pos,omitempty"` // <filename>:<line>
+	Root       bool         `json:"root,omitempty"`
+	Builds     []string     `json:"builds,omitempty"`
+	Incomplete bool         `json:"incomplete,omitempty"`
+	ReturnsErr bool         `json:"returnsError,omitempty"` // if Config.TrackErrors
+	Cover      *jsonCover   `json:"cover,omitempty"`
+	Cost       *jsonCost    `json:"cost,omitempty"`
+	Terminates *jsonTerm    `json:"terminates,omitempty"`
+	Entries    []string     `json:"entryPoints,omitempty"` // the registrars of the entry point
+	Effects    []jsonEffect `json:"effects,omitempty"`     // if ApplyEffects
+	Metrics    *jsonMetrics `json:"metrics,omitempty"`     // if Config.ShowMetrics
+	To         []jsonEdge   `json:"to,omitempty"`
+}
+
+type jsonCover struct {
+	Percent float64 `json:"percent"`
+	Covered int     `json:"covered"` // the number of covered statements
+	Total   int     `json:"total"`
+}
+
+type jsonCost struct {
+	Flat        int64   `json:"flat"`
+	Cum         int64   `json:"cum"`
+	FlatPercent float64 `json:"flatPercent"`
+	CumPercent  float64 `json:"cumPercent"`
+}
+
+type jsonTerm struct {
+	Sink string `json:"sink"`
+	Path []int  `json:"path"` // the node IDs of the call path to the function calling the sink
+}
+
+type jsonEffect struct {
+	Category string `json:"category"`
+	Call     string `json:"call"` // the called function matched by the category
+	Path     []int  `json:"path"` // the node IDs of the call path to the function calling Call
+}
+
+type jsonMetrics struct {
+	Lines           int `json:"lines"`
+	Complexity      int `json:"complexity"`
+	Params          int `json:"params"`
+	Results         int `json:"results"`
+	Depth           int `json:"depth"`
+	TotalLines      int `json:"totalLines"` // the functions reachable from the node, the node itself included
+	TotalComplexity int `json:"totalComplexity"`
+}
+
+type jsonEdge struct {
+	ID     int             `json:"id"`
+	Ref    bool            `json:"ref,omitempty"`
+	Builds []string        `json:"builds,omitempty"`
+	Errors []ErrorHandling `json:"errors,omitempty"` // how the errors returned by the callee are handled, if Config.TrackErrors
+}
+
+// DumpAllJSON dumps all nodes of the graph as JSON.
+func DumpAllJSON(w io.Writer, c *Config, g *Graph) error {
+	return dumpJSON(w, c, g, nil)
+}
+
+// DumpJSON dumps the nodes related to nodes (the callers and the callees, recursively) as JSON.
+func DumpJSON(w io.Writer, c *Config, g *Graph, nodes []*Node) error {
+	_, seen := selectNodes(g, nodes)
+	return dumpJSON(w, c, g, seen)
+}
+
+func dumpJSON(w io.Writer, c *Config, g *Graph, filter map[int]struct{}) error {
+	prefix := textPrefix(c.PkgPath)
+	var uncovered map[int]struct{}
+	if c.UncoveredOnly {
+		uncovered = uncoveredPaths(g)
+	}
+	need := func(n *Node) bool {
+		if filter != nil {
+			if _, ok := filter[n.ID]; !ok {
+				return false
+			}
+		}
+		if uncovered != nil {
+			if _, ok := uncovered[n.ID]; !ok {
+				return false
+			}
+		}
+		if belowMinCum(c, n) {
+			return false
+		}
+		return c.NeedNode(n)
+	}
+
+	out := jsonOutput{Package: c.PkgPath, Builds: c.Builds, Nodes: []jsonNode{}}
+	if len(c.PkgPaths) > 1 {
+		out.Packages = c.PkgPaths
+	}
+	g.Walk(func(n *Node) {
+		if !need(n) {
+			return
+		}
+		if n.Value.Kind == KindObject && len(n.To) == 0 {
+			return
+		}
+
+		jn := newJSONNode(c, n, prefix, need)
+		out.Nodes = append(out.Nodes, jn)
+	})
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// newJSONNode returns the JSON representation of the node, with the edges to the needed nodes.
+func newJSONNode(c *Config, n *Node, prefix string, need func(*Node) bool) jsonNode {
+	jn := jsonNode{
+		ID:         n.ID,
+		Key:        n.Value.ID,
+		Name:       n.Name,
+		Recv:       n.Value.Recv,
+		Kind:       n.Value.Kind,
+		Text:       nodeText(c, n, prefix),
+		Root:       isRoot(n),
+		Builds:     n.Value.Builds,
+		Incomplete: n.Value.Incomplete,
+		ReturnsErr: c.TrackErrors && returnsError(n),
+		Entries:    n.Value.EntryPoints,
+	}
+	if cover := n.Value.Cover; cover != nil {
+		jn.Cover = &jsonCover{Percent: cover.Percent(), Covered: cover.Covered, Total: cover.Total}
+	}
+	if cost := n.Value.Cost; cost != nil && c.Profile != nil {
+		jn.Cost = &jsonCost{Flat: cost.Flat, Cum: cost.Cum, FlatPercent: c.Profile.Percent(cost.Flat), CumPercent: c.Profile.Percent(cost.Cum)}
+	}
+	if t := n.Value.Termination; t != nil {
+		jn.Terminates = &jsonTerm{Sink: t.Sink}
+		for _, x := range t.Path {
+			jn.Terminates.Path = append(jn.Terminates.Path, x.ID)
+		}
+	}
+	for _, name := range effectNames(n) {
+		effect := n.Value.Effects[name]
+		je := jsonEffect{Category: name, Call: effect.Call}
+		for _, x := range effect.Path {
+			je.Path = append(je.Path, x.ID)
+		}
+		jn.Effects = append(jn.Effects, je)
+	}
+	if m := n.Value.Metrics; m != nil && c.ShowMetrics {
+		total := metricTotals{}.of(n)
+		jn.Metrics = &jsonMetrics{Lines: m.Lines, Complexity: m.Complexity, Params: m.Params, Results: m.Results, Depth: m.Depth, TotalLines: total.Lines, TotalComplexity: total.Complexity}
+	}
+	if pos := n.Value.Object.Pos(); pos.IsValid() {
+		position := c.Fset.Position(pos)
+		jn.Pos = fmt.Sprintf("%s:%d", position.Filename, position.Line)
+	}
+	for _, next := range n.To {
+		if !need(next) {
+			continue
+		}
+		jn.To = append(jn.To, jsonEdge{ID: next.ID, Ref: n.Value.Refs[next.Value.ID], Builds: n.Value.EdgeBuilds[next.Value.ID], Errors: n.Value.ErrorHandling[next.Value.ID]})
+	}
+	return jn
+}
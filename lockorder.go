@@ -0,0 +1,212 @@
+package goinspect
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"io"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// LockAcquire is a lock acquired in a function.
+type LockAcquire struct {
+	Lock string   // the mutex, <pkgpath>.<type>.<field> for struct fields, <pkgpath>.<name> for package-level variables
+	Held []string // the locks held at the acquisition
+}
+
+// LockFinding is a finding of the lock-order analysis.
+type LockFinding struct {
+	Held     string  // the lock held
+	Acquired string  // the lock acquired while Held is held, if Held == Acquired, it is a self-deadlock
+	Path     []*Node // the call path from the function holding Held, to the function acquiring Acquired
+}
+
+// lockCall returns the mutex of the call to Lock, RLock, Unlock or RUnlock of sync.Mutex or sync.RWMutex, and the method name.
+func lockCall(pkg *packages.Package, call *ast.CallExpr) (lock string, method string) {
+	sel, ok := unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return "", ""
+	}
+	selection, ok := pkg.TypesInfo.Selections[sel]
+	if !ok || selection.Kind() != types.MethodVal {
+		return "", ""
+	}
+	fn := selection.Obj()
+	switch fn.Name() {
+	case "Lock", "RLock", "Unlock", "RUnlock":
+	default:
+		return "", ""
+	}
+	if fn.Pkg() == nil || fn.Pkg().Path() != "sync" {
+		return "", ""
+	}
+
+	// the embedded mutex (e.g. c.Lock(), type C struct{ sync.Mutex })
+	if index := selection.Index(); len(index) > 1 {
+		if owner := namedOf(selection.Recv()); owner != nil {
+			if st, ok := owner.Underlying().(*types.Struct); ok {
+				return objectPath(owner.Obj()) + "." + st.Field(index[0]).Name(), fn.Name()
+			}
+		}
+		return "", ""
+	}
+
+	if lock := sharedVar(pkg, sel.X); lock != "" {
+		return lock, fn.Name()
+	}
+	return "", ""
+}
+
+// sharedVar returns the name of the variable shared between functions, <pkgpath>.<type>.<field> for struct fields (e.g. s.mu),
+// <pkgpath>.<name> for package-level variables (e.g. mu, pkg.mu), or "" for the others (e.g. local variables).
+func sharedVar(pkg *packages.Package, expr ast.Expr) string {
+	switch x := unparen(expr).(type) {
+	case *ast.SelectorExpr:
+		// <x>.<field>
+		if field, ok := pkg.TypesInfo.Selections[x]; ok && field.Kind() == types.FieldVal {
+			if owner := namedOf(field.Recv()); owner != nil {
+				return objectPath(owner.Obj()) + "." + x.Sel.Name
+			}
+		} else if v, ok := pkg.TypesInfo.Uses[x.Sel].(*types.Var); ok && v.Pkg() != nil && v.Parent() == v.Pkg().Scope() {
+			return objectPath(v) // <pkg>.<name>
+		}
+	case *ast.Ident:
+		// <name>, only package-level variables
+		if v, ok := pkg.TypesInfo.Uses[x].(*types.Var); ok && v.Pkg() != nil && v.Parent() == v.Pkg().Scope() {
+			return objectPath(v)
+		}
+	}
+	return ""
+}
+
+func namedOf(t types.Type) *types.Named {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, _ := t.(*types.Named)
+	return named
+}
+
+func objectPath(ob types.Object) string {
+	return ob.Pkg().Path() + "." + ob.Name()
+}
+
+// LockFindings returns the lock-order edges (a lock acquired while another lock is held) and the self-deadlocks (the same lock acquired again), along the call paths.
+// The locks held at the calls are approximated in the source order (Unlock in defer is treated as released at the return).
+func LockFindings(g *Graph) []LockFinding {
+	// the locks acquired by each function, directly or transitively, and the next function on the path to the acquisition (nil if directly)
+	acquires := map[int]map[string]*Node{}
+	var q []*Node
+	for _, n := range g.Nodes {
+		for _, l := range n.Value.Locks {
+			if acquires[n.ID] == nil {
+				acquires[n.ID] = map[string]*Node{}
+				q = append(q, n)
+			}
+			acquires[n.ID][l.Lock] = nil
+		}
+	}
+	for len(q) > 0 {
+		var n *Node
+		n, q = q[0], q[1:]
+		for _, prev := range n.From {
+			if prev.Value.Kind == KindObject || prev.Value.Refs[n.Value.ID] {
+				continue
+			}
+			added := false
+			for lock := range acquires[n.ID] {
+				if _, ok := acquires[prev.ID][lock]; ok {
+					continue
+				}
+				if acquires[prev.ID] == nil {
+					acquires[prev.ID] = map[string]*Node{}
+				}
+				acquires[prev.ID][lock] = n
+				added = true
+			}
+			if added {
+				q = append(q, prev)
+			}
+		}
+	}
+	pathTo := func(n *Node, lock string) []*Node {
+		path := []*Node{n}
+		for x := acquires[n.ID][lock]; x != nil; x = acquires[x.ID][lock] {
+			path = append(path, x)
+		}
+		return path
+	}
+
+	seen := map[[2]string]bool{}
+	var findings []LockFinding
+	add := func(held string, acquired string, path []*Node) {
+		if key := [2]string{held, acquired}; !seen[key] {
+			seen[key] = true
+			findings = append(findings, LockFinding{Held: held, Acquired: acquired, Path: path})
+		}
+	}
+	for _, n := range g.Nodes {
+		for _, l := range n.Value.Locks {
+			for _, held := range l.Held {
+				add(held, l.Lock, []*Node{n})
+			}
+		}
+		for _, next := range n.To {
+			held := n.Value.HeldLocks[next.Value.ID]
+			if len(held) == 0 {
+				continue
+			}
+			locks := make([]string, 0, len(acquires[next.ID]))
+			for lock := range acquires[next.ID] {
+				locks = append(locks, lock)
+			}
+			sort.Strings(locks)
+			for _, lock := range locks {
+				for _, h := range held {
+					add(h, lock, append([]*Node{n}, pathTo(next, lock)...))
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// DumpLockFindings dumps the lock-order graph, the inversions (the locks acquired in both orders), and the self-deadlocks.
+//
+//	order: <held> -> <acquired>  <path>
+//	inversion: <lock> <-> <lock>
+//	self-deadlock: <lock>  <path>
+func DumpLockFindings(w io.Writer, c *Config, findings []LockFinding) error {
+	prefix := textPrefix(c.PkgPath)
+	trim := func(lock string) string { return strings.ReplaceAll(lock, prefix, "") }
+
+	order := map[[2]string]bool{}
+	var lines []string
+	for _, f := range findings {
+		texts := make([]string, len(f.Path))
+		for i, x := range f.Path {
+			texts[i] = nodeText(c, x, prefix)
+		}
+		if f.Held == f.Acquired {
+			lines = append(lines, fmt.Sprintf("self-deadlock: %s  %s", trim(f.Held), strings.Join(texts, " -> ")))
+			continue
+		}
+		order[[2]string{f.Held, f.Acquired}] = true
+		lines = append(lines, fmt.Sprintf("order: %s -> %s  %s", trim(f.Held), trim(f.Acquired), strings.Join(texts, " -> ")))
+	}
+	for pair := range order {
+		if pair[0] < pair[1] && order[[2]string{pair[1], pair[0]}] {
+			lines = append(lines, fmt.Sprintf("inversion: %s <-> %s", trim(pair[0]), trim(pair[1])))
+		}
+	}
+	sort.Strings(lines)
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+package goinspect
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LSPCommandTree is the command of workspace/executeCommand, returning the text tree of the symbol under the cursor (the same as --only).
+const LSPCommandTree = "goinspect.tree"
+
+// LSPServer is a language server over stdio (JSON-RPC 2.0), providing the call hierarchy of the scanned graph.
+//
+//	textDocument/prepareCallHierarchy  the declaration under the cursor
+//	callHierarchy/incomingCalls        the callers
+//	callHierarchy/outgoingCalls        the callees
+//	workspace/executeCommand           "goinspect.tree" with [{"textDocument": {"uri": ...}, "position": ...}]
+//
+// The characters of positions are counted in bytes, not in UTF-16 code units.
+type LSPServer struct {
+	Config *Config
+	Graph  *Graph
+
+	nodes map[int]*Node
+}
+
+// NewLSPServer returns the language server of the scanned graph.
+func NewLSPServer(c *Config, g *Graph) *LSPServer {
+	s := &LSPServer{Config: c, Graph: g, nodes: make(map[int]*Node, len(g.Nodes))}
+	for _, n := range g.Nodes {
+		s.nodes[n.ID] = n
+	}
+	return s
+}
+
+type lspRequest struct {
+	ID     json.RawMessage `json:"id,omitempty"` // if empty, the message is a notification
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspTextDocumentPositionParams struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Position lspPosition `json:"position"`
+}
+
+type lspCallHierarchyItem struct {
+	Name           string   `json:"name"`
+	Kind           int      `json:"kind"` // SymbolKind
+	Detail         string   `json:"detail,omitempty"`
+	URI            string   `json:"uri"`
+	Range          lspRange `json:"range"`
+	SelectionRange lspRange `json:"selectionRange"`
+	Data           struct {
+		ID int `json:"id"`
+	} `json:"data"`
+}
+
+type lspCallHierarchyCall struct {
+	From       *lspCallHierarchyItem `json:"from,omitempty"` // incoming calls
+	To         *lspCallHierarchyItem `json:"to,omitempty"`   // outgoing calls
+	FromRanges []lspRange            `json:"fromRanges"`     // the call sites are not recorded, always empty
+}
+
+// SymbolKind of LSP
+const (
+	lspSymbolKindMethod   = 6
+	lspSymbolKindFunction = 12
+	lspSymbolKindStruct   = 23
+)
+
+// Serve reads the requests from r, and writes the responses to w, until the exit notification or EOF.
+func (s *LSPServer) Serve(r io.Reader, w io.Writer) error {
+	br := bufio.NewReader(r)
+	for {
+		body, err := readLSPMessage(br)
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		var req lspRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			res := map[string]interface{}{"jsonrpc": "2.0", "id": nil, "error": &lspError{Code: -32700, Message: err.Error()}} // parse error, the id is unknown
+			if err := writeLSPMessage(w, res); err != nil {
+				return err
+			}
+			continue
+		}
+		if req.Method == "exit" {
+			return nil
+		}
+
+		result, rpcErr := s.handle(req)
+		if len(req.ID) == 0 {
+			continue // notification
+		}
+		res := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		if rpcErr != nil {
+			res["error"] = rpcErr
+		} else {
+			res["result"] = result
+		}
+		if err := writeLSPMessage(w, res); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *LSPServer) handle(req lspRequest) (interface{}, *lspError) {
+	switch req.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"callHierarchyProvider":  true,
+				"executeCommandProvider": map[string]interface{}{"commands": []string{LSPCommandTree}},
+			},
+			"serverInfo": map[string]string{"name": "goinspect"},
+		}, nil
+	case "initialized", "shutdown":
+		return nil, nil
+	case "textDocument/prepareCallHierarchy":
+		var params lspTextDocumentPositionParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &lspError{Code: -32602, Message: err.Error()}
+		}
+		n := s.nodeAt(params)
+		if n == nil {
+			return nil, nil
+		}
+		return []lspCallHierarchyItem{s.item(n)}, nil
+	case "callHierarchy/incomingCalls", "callHierarchy/outgoingCalls":
+		var params struct {
+			Item lspCallHierarchyItem `json:"item"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &lspError{Code: -32602, Message: err.Error()}
+		}
+		n, ok := s.nodes[params.Item.Data.ID]
+		if !ok {
+			return nil, &lspError{Code: -32602, Message: fmt.Sprintf("node is not found, %d", params.Item.Data.ID)}
+		}
+		calls := []lspCallHierarchyCall{}
+		if req.Method == "callHierarchy/incomingCalls" {
+			for _, prev := range n.From {
+				if s.need(prev) {
+					item := s.item(prev)
+					calls = append(calls, lspCallHierarchyCall{From: &item, FromRanges: []lspRange{}})
+				}
+			}
+		} else {
+			for _, next := range n.To {
+				if s.need(next) {
+					item := s.item(next)
+					calls = append(calls, lspCallHierarchyCall{To: &item, FromRanges: []lspRange{}})
+				}
+			}
+		}
+		return calls, nil
+	case "workspace/executeCommand":
+		var params struct {
+			Command   string                          `json:"command"`
+			Arguments []lspTextDocumentPositionParams `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &lspError{Code: -32602, Message: err.Error()}
+		}
+		if params.Command != LSPCommandTree || len(params.Arguments) != 1 {
+			return nil, &lspError{Code: -32602, Message: fmt.Sprintf("unexpected command %q, (%s with 1 argument)", params.Command, LSPCommandTree)}
+		}
+		n := s.nodeAt(params.Arguments[0])
+		if n == nil {
+			return nil, nil
+		}
+		buf := new(bytes.Buffer)
+		if err := Dump(buf, s.Config, s.Graph, []*Node{n}); err != nil {
+			return nil, &lspError{Code: -32603, Message: err.Error()}
+		}
+		return buf.String(), nil
+	}
+	return nil, &lspError{Code: -32601, Message: fmt.Sprintf("method not found, %s", req.Method)}
+}
+
+// nodeAt returns the node whose declaration includes the position.
+func (s *LSPServer) nodeAt(params lspTextDocumentPositionParams) *Node {
+	filename := uriToFilename(params.TextDocument.URI)
+	line, col := params.Position.Line+1, params.Position.Character+1 // 1-based
+	for _, n := range s.Graph.Nodes {
+		if n.Value.Decl == nil || !s.need(n) {
+			continue
+		}
+		start, end := s.Config.Fset.Position(n.Value.Decl.Pos()), s.Config.Fset.Position(n.Value.Decl.End())
+		if start.Filename != filename {
+			continue
+		}
+		if (start.Line < line || (start.Line == line && start.Column <= col)) && (line < end.Line || (line == end.Line && col <= end.Column)) {
+			return n
+		}
+	}
+	return nil
+}
+
+func (s *LSPServer) item(n *Node) lspCallHierarchyItem {
+	kind := lspSymbolKindFunction
+	switch n.Value.Kind {
+	case KindMethod:
+		kind = lspSymbolKindMethod
+	case KindObject:
+		kind = lspSymbolKindStruct
+	}
+	item := lspCallHierarchyItem{Name: n.Name, Kind: kind, Detail: nodeText(s.Config, n, textPrefix(s.Config.PkgPath))}
+	if n.Value.Recv != "" {
+		item.Name = n.Value.Recv + "." + n.Name
+	}
+	item.Data.ID = n.ID
+
+	if pos := n.Value.Object.Pos(); pos.IsValid() {
+		start := s.Config.Fset.Position(pos)
+		item.URI = filenameToURI(start.Filename)
+		name := lspPosition{Line: start.Line - 1, Character: start.Column - 1}
+		item.SelectionRange = lspRange{Start: name, End: lspPosition{Line: name.Line, Character: name.Character + len(n.Name)}}
+		item.Range = item.SelectionRange
+	}
+	if n.Value.Decl != nil {
+		start, end := s.Config.Fset.Position(n.Value.Decl.Pos()), s.Config.Fset.Position(n.Value.Decl.End())
+		item.Range = lspRange{Start: lspPosition{Line: start.Line - 1, Character: start.Column - 1}, End: lspPosition{Line: end.Line - 1, Character: end.Column - 1}}
+	}
+	return item
+}
+
+func (s *LSPServer) need(n *Node) bool {
+	return s.Config.NeedNode(n)
+}
+
+func uriToFilename(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+func filenameToURI(filename string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(filename)}).String()
+}
+
+// readLSPMessage reads the body of a message ("Content-Length: <n>\r\n\r\n<body>").
+func readLSPMessage(r *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	size, err := strconv.Atoi(strings.TrimSpace(header.Get("Content-Length")))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	return body, nil
+}
+
+func writeLSPMessage(w io.Writer, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshal response: %w", err)
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+		return fmt.Errorf("write response: %w", err)
+	}
+	return nil
+}
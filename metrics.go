@@ -0,0 +1,207 @@
+package goinspect
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"io"
+	"sort"
+)
+
+// Metrics is the code metrics of a function or method, computed from the declaration.
+type Metrics struct {
+	Lines      int // the lines of the declaration
+	Complexity int // the cyclomatic complexity, 1 + the number of branches (if, for, case, &&, ||)
+	Params     int
+	Results    int
+	Depth      int // the max nesting depth of the control statements (and closures)
+}
+
+// computeMetrics returns the metrics of the function declaration. The closures are counted as a part of the function.
+func computeMetrics(fset *token.FileSet, ob types.Object, decl *ast.FuncDecl) *Metrics {
+	m := &Metrics{
+		Lines:      fset.Position(decl.End()).Line - fset.Position(decl.Pos()).Line + 1,
+		Complexity: 1,
+	}
+	if sig, ok := ob.Type().(*types.Signature); ok {
+		m.Params = sig.Params().Len()
+		m.Results = sig.Results().Len()
+	}
+	if decl.Body == nil {
+		return m
+	}
+
+	var stack []ast.Node
+	var nests []bool // whether each node of stack increments depth
+	depth := 0
+	nested := func(t ast.Node) bool {
+		switch t := t.(type) {
+		case *ast.IfStmt:
+			if parent, ok := stack[len(stack)-1].(*ast.IfStmt); ok && parent.Else == t {
+				return false // else if
+			}
+			return true
+		case *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt, *ast.FuncLit:
+			return true
+		}
+		return false
+	}
+	ast.Inspect(decl.Body, func(t ast.Node) bool {
+		if t == nil {
+			if nests[len(nests)-1] {
+				depth--
+			}
+			stack, nests = stack[:len(stack)-1], nests[:len(nests)-1]
+			return true
+		}
+		nest := len(stack) > 0 && nested(t)
+		if nest {
+			depth++
+			if depth > m.Depth {
+				m.Depth = depth
+			}
+		}
+		stack, nests = append(stack, t), append(nests, nest)
+
+		switch t := t.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			m.Complexity++
+		case *ast.CaseClause:
+			if t.List != nil { // not default
+				m.Complexity++
+			}
+		case *ast.CommClause:
+			if t.Comm != nil { // not default
+				m.Complexity++
+			}
+		case *ast.BinaryExpr:
+			if t.Op == token.LAND || t.Op == token.LOR {
+				m.Complexity++
+			}
+		}
+		return true
+	})
+	return m
+}
+
+// metricTotals is the cache of the metrics summed over the functions reachable from each node (the node itself included).
+type metricTotals map[int]*Metrics
+
+// of returns the total lines and complexity of the functions reachable from n, the edges of the references (not called) are not followed.
+func (totals metricTotals) of(n *Node) *Metrics {
+	if m, ok := totals[n.ID]; ok {
+		return m
+	}
+	m := &Metrics{}
+	seen := map[int]bool{n.ID: true}
+	q := []*Node{n}
+	for len(q) > 0 {
+		var x *Node
+		x, q = q[0], q[1:]
+		if x.Value.Metrics != nil {
+			m.Lines += x.Value.Metrics.Lines
+			m.Complexity += x.Value.Metrics.Complexity
+		}
+		for _, next := range x.To {
+			if !seen[next.ID] && !x.Value.Refs[next.Value.ID] {
+				seen[next.ID] = true
+				q = append(q, next)
+			}
+		}
+	}
+	totals[n.ID] = m
+	return m
+}
+
+type rowMetrics struct {
+	self  *Metrics
+	total *Metrics // if the node has callees
+}
+
+func newRowMetrics(c *Config, n *Node, totals metricTotals) *rowMetrics {
+	if !c.ShowMetrics || (n.Value.Metrics == nil && len(n.To) == 0) {
+		return nil
+	}
+	r := &rowMetrics{self: n.Value.Metrics}
+	if len(n.To) > 0 {
+		r.total = totals.of(n)
+	}
+	return r
+}
+
+// metricsText returns the annotation of the metrics, e.g. "loc:12 cc:3 params:2 results:1 depth:2  total(loc:80 cc:15)".
+func metricsText(r *rowMetrics) string {
+	text := ""
+	if m := r.self; m != nil {
+		text = fmt.Sprintf("loc:%d cc:%d params:%d results:%d depth:%d", m.Lines, m.Complexity, m.Params, m.Results, m.Depth)
+	}
+	if m := r.total; m != nil {
+		if text != "" {
+			text += "  "
+		}
+		text += fmt.Sprintf("total(loc:%d cc:%d)", m.Lines, m.Complexity)
+	}
+	return text
+}
+
+// DumpStats dumps the summary of the graph, the numbers of the functions, edges and roots, and the metrics of the functions (with the most complex ones).
+func DumpStats(w io.Writer, c *Config, g *Graph) error {
+	prefix := textPrefix(c.PkgPath)
+	need := c.NeedNode
+
+	var funcs []*Node
+	edges, roots := 0, 0
+	total := &Metrics{}
+	for _, n := range g.Nodes {
+		if n.Value.Kind == KindObject || !need(n) {
+			continue
+		}
+		if isRoot(n) {
+			roots++
+		}
+		for _, next := range n.To {
+			if need(next) {
+				edges++
+			}
+		}
+		if m := n.Value.Metrics; m != nil {
+			funcs = append(funcs, n)
+			total.Lines += m.Lines
+			total.Complexity += m.Complexity
+			if m.Depth > total.Depth {
+				total.Depth = m.Depth
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "functions: %d\n", len(funcs))
+	fmt.Fprintf(w, "edges: %d\n", edges)
+	fmt.Fprintf(w, "roots: %d\n", roots)
+	fmt.Fprintf(w, "lines: %d\n", total.Lines)
+	fmt.Fprintf(w, "complexity: %d\n", total.Complexity)
+	if len(funcs) > 0 {
+		fmt.Fprintf(w, "complexity (avg): %.1f\n", float64(total.Complexity)/float64(len(funcs)))
+	}
+	fmt.Fprintf(w, "depth (max): %d\n", total.Depth)
+
+	sort.SliceStable(funcs, func(i, j int) bool {
+		x, y := funcs[i].Value.Metrics, funcs[j].Value.Metrics
+		if x.Complexity != y.Complexity {
+			return x.Complexity > y.Complexity
+		}
+		return x.Lines > y.Lines
+	})
+	if len(funcs) > 10 {
+		funcs = funcs[:10]
+	}
+	totals := metricTotals{}
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "# the most complex functions")
+	for _, n := range funcs {
+		if _, err := fmt.Fprintf(w, "%s  %s\n", nodeText(c, n, prefix), metricsText(&rowMetrics{self: n.Value.Metrics, total: totals.of(n)})); err != nil {
+			return err
+		}
+	}
+	return nil
+}
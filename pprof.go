@@ -0,0 +1,253 @@
+package goinspect
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/pprof/profile"
+)
+
+// Profile is the sampled values of a pprof profile (e.g. cpu, heap), aggregated by function.
+// The closures are aggregated into the enclosing functions (e.g. x.F.func1 -> x.F).
+type Profile struct {
+	SampleType string // e.g. cpu, alloc_space
+	Unit       string // e.g. nanoseconds, bytes
+	Total      int64
+
+	Funcs map[string]*Cost            // function name -> cost
+	Edges map[string]map[string]int64 // caller function name -> callee function name -> value
+}
+
+// Cost is the sampled values of a function.
+type Cost struct {
+	Flat int64 // the value of the function itself
+	Cum  int64 // the value of the function and its callees
+}
+
+// ProfileEdge is a call edge seen in the profile.
+type ProfileEdge struct {
+	Caller *Node
+	Callee *Node
+	Value  int64
+}
+
+// LoadProfile reads the pprof profile (gzipped or not), with the values of sampleType (e.g. cpu, alloc_space).
+// If sampleType is empty, the default sample type of the profile (or the last one) is used.
+func LoadProfile(r io.Reader, sampleType string) (*Profile, error) {
+	p, err := profile.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if sampleType == "" {
+		sampleType = p.DefaultSampleType
+	}
+	index := len(p.SampleType) - 1
+	if sampleType != "" {
+		index = -1
+		names := make([]string, len(p.SampleType))
+		for i, st := range p.SampleType {
+			names[i] = st.Type
+			if st.Type == sampleType {
+				index = i
+			}
+		}
+		if index < 0 {
+			return nil, fmt.Errorf("sample type %q is not found, (%s)", sampleType, strings.Join(names, ", "))
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("no sample types")
+	}
+
+	out := &Profile{
+		SampleType: p.SampleType[index].Type,
+		Unit:       p.SampleType[index].Unit,
+		Funcs:      map[string]*Cost{},
+		Edges:      map[string]map[string]int64{},
+	}
+	for _, s := range p.Sample {
+		v := s.Value[index]
+		if v == 0 {
+			continue
+		}
+		out.Total += v
+
+		// the stack, from the leaf to the root (including the inlined functions)
+		var stack []string
+		for _, loc := range s.Location {
+			for _, line := range loc.Line {
+				if line.Function == nil {
+					continue
+				}
+				name := enclosingFunc(line.Function.Name)
+				if len(stack) > 0 && stack[len(stack)-1] == name {
+					continue // e.g. the closure called in the enclosing function
+				}
+				stack = append(stack, name)
+			}
+		}
+		if len(stack) == 0 {
+			continue
+		}
+
+		out.cost(stack[0]).Flat += v
+		seen := make(map[string]bool, len(stack))
+		for i, name := range stack {
+			if !seen[name] { // recursion
+				seen[name] = true
+				out.cost(name).Cum += v
+			}
+			if i > 0 {
+				if out.Edges[name] == nil {
+					out.Edges[name] = map[string]int64{}
+				}
+				out.Edges[name][stack[i-1]] += v // caller -> callee
+			}
+		}
+	}
+	return out, nil
+}
+
+// Percent returns the percentage of v in the total.
+func (p *Profile) Percent(v int64) float64 {
+	if p.Total == 0 {
+		return 0
+	}
+	return 100 * float64(v) / float64(p.Total)
+}
+
+// cumPercent returns the percentage of the cumulative value of the node (0, if not sampled).
+func (p *Profile) cumPercent(n *Node) float64 {
+	if n.Value.Cost == nil {
+		return 0
+	}
+	return p.Percent(n.Value.Cost.Cum)
+}
+
+// belowMinCum reports whether the cumulative value of the node is under Config.MinCum.
+func belowMinCum(c *Config, n *Node) bool {
+	return c.Profile != nil && c.MinCum > 0 && n.Value.Kind != KindObject && c.Profile.cumPercent(n) < c.MinCum
+}
+
+func (p *Profile) cost(name string) *Cost {
+	c, ok := p.Funcs[name]
+	if !ok {
+		c = &Cost{}
+		p.Funcs[name] = c
+	}
+	return c
+}
+
+var closureSuffix = regexp.MustCompile(`(\.func\d+(\.\d+)*|\.gowrap\d+|\.deferwrap\d+)+$`)
+
+// enclosingFunc returns the name of the enclosing function of closures (e.g. x.F.func1.2 -> x.F).
+func enclosingFunc(name string) string {
+	return closureSuffix.ReplaceAllString(name, "")
+}
+
+// ProfileReport is the comparison of the call edges in the profile with the scanned graph.
+type ProfileReport struct {
+	Missing []ProfileEdge // the edges seen in the profile but not found by the scanner (e.g. the calls through interfaces or function values)
+	Scanned int           // the number of the edges in the profile between the scanned functions
+}
+
+// ApplyProfile sets the cost of the functions and methods in the profile (Subject.Cost), and compares the call edges in the profile with the graph.
+func ApplyProfile(c *Config, g *Graph, p *Profile) *ProfileReport {
+	c.Profile = p
+	byName := map[string]*Node{}
+	for _, n := range g.Nodes {
+		if _, ok := n.Value.Decl.(*ast.FuncDecl); !ok {
+			continue
+		}
+		name := pprofName(n)
+		byName[name] = n
+		if cost, ok := p.Funcs[name]; ok {
+			n.Value.Cost = cost
+		}
+	}
+
+	report := &ProfileReport{}
+	for callerName, callees := range p.Edges {
+		caller, ok := byName[callerName]
+		if !ok {
+			continue
+		}
+		for calleeName, v := range callees {
+			callee, ok := byName[calleeName]
+			if !ok {
+				continue
+			}
+			report.Scanned++
+			found := false
+			for _, next := range caller.To {
+				if next == callee {
+					found = true
+					break
+				}
+			}
+			if !found {
+				report.Missing = append(report.Missing, ProfileEdge{Caller: caller, Callee: callee, Value: v})
+			}
+		}
+	}
+	sort.Slice(report.Missing, func(i, j int) bool {
+		x, y := report.Missing[i], report.Missing[j]
+		if x.Value != y.Value {
+			return x.Value > y.Value
+		}
+		if x.Caller.ID != y.Caller.ID {
+			return x.Caller.ID < y.Caller.ID
+		}
+		return x.Callee.ID < y.Callee.ID
+	})
+	c.ProfileReport = report
+	return report
+}
+
+// pprofName returns the function name of the node in pprof profiles (e.g. x.F, x.(*W).M, x.W.M).
+// The dots in the last element of the package path are escaped (e.g. gopkg.in/yaml%2ev3.Marshal).
+func pprofName(n *Node) string {
+	pkgpath := pkgPathOf(n)
+	if i := strings.LastIndex(pkgpath, "/"); i >= 0 {
+		pkgpath = pkgpath[:i] + strings.ReplaceAll(pkgpath[i:], ".", "%2e")
+	} else {
+		pkgpath = strings.ReplaceAll(pkgpath, ".", "%2e")
+	}
+	if n.Value.Recv == "" {
+		return pkgpath + "." + n.Name
+	}
+	recv := n.Value.Recv
+	if fn, ok := n.Value.Object.(*types.Func); ok {
+		t := fn.Type().(*types.Signature).Recv().Type()
+		ptr, isPtr := t.(*types.Pointer)
+		if isPtr {
+			t = ptr.Elem()
+		}
+		if named, ok := t.(*types.Named); ok && named.TypeParams().Len() > 0 {
+			recv += "[...]"
+		}
+		if isPtr {
+			recv = "(*" + recv + ")"
+		}
+	}
+	return pkgpath + "." + recv + "." + n.Name
+}
+
+// DumpProfileReport dumps the call edges seen in the profile but not found by the scanner, and the precision of the scanner.
+func DumpProfileReport(w io.Writer, c *Config, report *ProfileReport) error {
+	prefix := textPrefix(c.PkgPath)
+	for _, e := range report.Missing {
+		if _, err := fmt.Fprintf(w, "%s -> %s  %.1f%%\n", nodeText(c, e.Caller, prefix), nodeText(c, e.Callee, prefix), c.Profile.Percent(e.Value)); err != nil {
+			return err
+		}
+	}
+	found := report.Scanned - len(report.Missing)
+	_, err := fmt.Fprintf(w, "# %d of %d edges in the profile (between the scanned functions) are found statically\n", found, report.Scanned)
+	return err
+}
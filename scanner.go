@@ -2,10 +2,9 @@ package goinspect
 
 import (
 	"go/ast"
+	"go/token"
 	"go/types"
 	"strconv"
-	"strings"
-	"sync"
 
 	"github.com/podhmo/goinspect/graph"
 	"golang.org/x/tools/go/packages"
@@ -19,6 +18,38 @@ type Subject struct {
 	Object types.Object
 	Recv   string // if method, this value is not zero
 	Kind   Kind
+	Decl   ast.Node // the declaration (*ast.FuncDecl or *ast.TypeSpec), if scanned
+
+	Refs map[string]bool // subject IDs used as function values (not called), if Config.IncludeReferences
+
+	Builds     []string            // the build configurations that the subject exists in, if ScanBuilds
+	EdgeBuilds map[string][]string // subject ID -> the build configurations that the edge exists in, if ScanBuilds
+
+	Incomplete bool // the declaration has errors, if Config.KeepGoing
+
+	Cover *Cover // the statement coverage of the function, if ApplyCoverage
+	Cost  *Cost  // the sampled values of the function, if ApplyProfile
+
+	ErrorHandling map[string][]ErrorHandling // subject ID -> how the errors returned by the callee are handled, if Config.TrackErrors
+
+	Sinks       []string     // the terminating functions called directly (e.g. panic, os.Exit, log.Fatalf)
+	Termination *Termination // the witness of reaching the sinks, if ApplyTerminations
+
+	NewContexts map[string]string // subject ID -> the new context passed to the callee (e.g. context.Background())
+
+	Locks     []LockAcquire       // the locks acquired (sync.Mutex, sync.RWMutex)
+	HeldLocks map[string][]string // subject ID -> the locks held at the call
+
+	EntryPoints []string // the registrars that the function or method is passed to (e.g. net/http.HandleFunc), treated as a root
+
+	EffectCalls map[string]string  // category -> the function called directly, matched by Config.Effects (e.g. db -> database/sql.DB.Exec)
+	Effects     map[string]*Effect // category -> the witness of the side effect, if ApplyEffects
+
+	Metrics *Metrics // computed from the declaration (*ast.FuncDecl)
+
+	Spawns []string // subject IDs run as goroutines (go f(), or called in go func() { ... }())
+	Sends  []string // the channels sent to (channel-typed struct fields or package-level variables, see CommMap)
+	Recvs  []string // the channels received from (<-ch, range ch)
 }
 
 type Kind string
@@ -31,11 +62,30 @@ const (
 
 type Scanner struct {
 	g      *Graph
-	pkgMap map[string]*packages.Package
+	pkgMap map[string]bool  // the loaded packages
+	inits  map[string]int   // pkg.PkgPath -> the number of init functions
+	build  string           // the name of current build configuration, if ScanBuilds
+	errors map[string][]int // filename -> the lines of errors, if Config.KeepGoing
+
+	registrars map[string]Registrar // Registrar.Name -> Registrar, Config.Registrars and DefaultRegistrars
 
 	Config *Config
 }
 
+// NewGraph returns an empty graph of subjects.
+func NewGraph() *Graph {
+	return graph.New(func(s *Subject) string { return s.ID })
+}
+
+// NewScanner returns the scanner adding the nodes to g. The calls into the packages of pkgpaths are linked, and the others are skipped (or treated as external leaf nodes, if Config.ShowExternal).
+func NewScanner(c *Config, g *Graph, pkgpaths []string) *Scanner {
+	pkgMap := make(map[string]bool, len(pkgpaths))
+	for _, path := range pkgpaths {
+		pkgMap[path] = true
+	}
+	return &Scanner{g: g, pkgMap: pkgMap, Config: c}
+}
+
 func (s *Scanner) Scan(pkg *packages.Package, t *ast.File) error {
 	f := &file{t: t}
 	for _, decl := range t.Decls {
@@ -51,6 +101,12 @@ func (s *Scanner) Scan(pkg *packages.Package, t *ast.File) error {
 					if err := s.scanTypeSpec(pkg, f, spec); err != nil {
 						return err
 					}
+				case *ast.ValueSpec:
+					if s.Config.IncludeInit && decl.Tok == token.VAR {
+						if err := s.scanValueSpec(pkg, f, spec); err != nil {
+							return err
+						}
+					}
 				}
 			}
 		}
@@ -66,14 +122,28 @@ func (s *Scanner) scanFuncDecl(pkg *packages.Package, f *file, decl *ast.FuncDec
 	if decl.Recv == nil {
 		// function decl
 		ob := pkg.TypesInfo.Defs[decl.Name]
-		id := pkg.ID + "." + decl.Name.Name
+		if ob == nil {
+			return nil // broken
+		}
+		id := pkg.PkgPath + "." + decl.Name.Name
+		if s.Config.IncludeInit && decl.Name.Name == "init" {
+			// init functions can be declared multiple times (<pkg>.init.0, <pkg>.init.1, ...)
+			if s.inits == nil {
+				s.inits = map[string]int{}
+			}
+			id = id + "." + strconv.Itoa(s.inits[pkg.PkgPath])
+			s.inits[pkg.PkgPath]++
+		}
 		subject := &Subject{ID: id, Object: ob, Kind: KindFunc}
-		node = s.g.Madd(subject)
+		node = s.add(subject)
 		node.Name = decl.Name.Name
 
 	} else {
 		// method decl
 		ob := pkg.TypesInfo.Defs[decl.Name]
+		if ob == nil {
+			return nil // broken
+		}
 		if sig, ok := ob.Type().(*types.Signature); ok {
 			recv := sig.Recv()
 			recvType := recv.Type()
@@ -82,81 +152,378 @@ func (s *Scanner) scanFuncDecl(pkg *packages.Package, f *file, decl *ast.FuncDec
 			}
 			if named, ok := recvType.(*types.Named); ok {
 				typob := named.Obj()
-				parentId := pkg.ID + "." + typob.Name()
-				parent := s.g.Madd(&Subject{ID: parentId, Object: typob, Kind: KindObject})
+				parentId := pkg.PkgPath + "." + typob.Name()
+				parent := s.add(&Subject{ID: parentId, Object: typob, Kind: KindObject})
 				parent.Name = typob.Name()
 
 				id := parentId + "#" + decl.Name.Name
 				subject := &Subject{ID: id, Object: ob, Recv: typob.Name(), Kind: KindMethod}
-				node = s.g.Madd(subject)
+				node = s.add(subject)
 				node.Name = decl.Name.Name
 				if s.Config.IncludeStruct {
-					s.g.LinkTo(parent, node)
+					s.linkTo(parent, node)
 				}
 			}
 		}
 	}
 
-	ast.Inspect(decl.Body, func(t ast.Node) bool {
+	if node == nil {
+		return nil
+	}
+	node.Value.Decl = decl
+	if s.Config.ShowMetrics {
+		node.Value.Metrics = computeMetrics(pkg.Fset, node.Value.Object, decl)
+	}
+	if s.hasErrors(pkg, decl) {
+		node.Value.Incomplete = true
+	}
+	if decl.Body != nil { // nil if implemented outside Go (e.g. assembly)
+		s.scanBody(pkg, f, node, decl.Body)
+	}
+	return nil
+}
+
+func (s *Scanner) scanValueSpec(pkg *packages.Package, f *file, spec *ast.ValueSpec) error {
+	// var <name> <type> = <value>
+	// var <name>, <name> = <value>, <value>
+	//
+	// the calls in initializers are linked from the synthetic <pkg>.init node.
+
+	if len(spec.Values) == 0 {
+		return nil
+	}
+
+	ob := types.NewVar(spec.Pos(), pkg.Types, pkg.Name+".init", types.NewSignatureType(nil, nil, nil, nil, nil, false)) // var <pkg>.init func()
+	node := s.add(&Subject{ID: pkg.PkgPath + ".init", Object: ob, Kind: KindFunc})
+	node.Name = "init"
+	for _, value := range spec.Values {
+		s.scanBody(pkg, f, node, value)
+	}
+	return nil
+}
+
+func (s *Scanner) scanBody(pkg *packages.Package, f *file, node *Node, body ast.Node) {
+	var stack []ast.Node // the ancestors of the current node
+
+	// the locks held, in the source order (the closures start without locks)
+	var held []string
+	var heldStack [][]string
+	deferred := map[*ast.CallExpr]bool{}
+
+	ast.Inspect(body, func(t ast.Node) bool {
+		if t == nil {
+			if _, ok := stack[len(stack)-1].(*ast.FuncLit); ok {
+				held, heldStack = heldStack[len(heldStack)-1], heldStack[:len(heldStack)-1]
+			}
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		stack = append(stack, t)
+
 		switch t := t.(type) {
-		case *ast.CallExpr:
-			switch sym := t.Fun.(type) {
-			case *ast.SelectorExpr:
-				// <x>.<sel>
-				if selection, ok := pkg.TypesInfo.Selections[sym]; ok {
-					// invoke method <object>.<name>()
-					fn := selection.Obj()
-					recvType := selection.Recv()
-					if t, ok := recvType.(*types.Pointer); ok {
-						recvType = t.Elem()
+		case *ast.FuncLit:
+			heldStack = append(heldStack, held)
+			held = nil
+		case *ast.DeferStmt:
+			deferred[t.Call] = true
+		case *ast.AssignStmt:
+			// <x>.<field> = <value>
+			if s.Config.TrackEntryPoints && len(t.Lhs) == len(t.Rhs) {
+				for i, lhs := range t.Lhs {
+					if sel, ok := unparen(lhs).(*ast.SelectorExpr); ok {
+						if field, ok := pkg.TypesInfo.Selections[sel]; ok && field.Kind() == types.FieldVal {
+							s.scanRegistrationField(pkg, field.Recv(), sel.Sel.Name, t.Rhs[i])
+						}
 					}
-					if named, ok := recvType.(*types.Named); ok {
-						p := fn.Pkg()
-						if p == nil {
-							return true
+				}
+			}
+		case *ast.SendStmt:
+			if s.Config.TrackComm {
+				if name := chanVar(pkg, t.Chan); name != "" {
+					node.Value.Sends = appendUnique(node.Value.Sends, name)
+				}
+			}
+		case *ast.UnaryExpr:
+			if s.Config.TrackComm && t.Op == token.ARROW {
+				if name := chanVar(pkg, t.X); name != "" {
+					node.Value.Recvs = appendUnique(node.Value.Recvs, name)
+				}
+			}
+		case *ast.RangeStmt:
+			if s.Config.TrackComm {
+				if name := chanVar(pkg, t.X); name != "" {
+					node.Value.Recvs = appendUnique(node.Value.Recvs, name)
+				}
+			}
+		case *ast.CallExpr:
+			s.scanEffects(pkg, node, t)
+			if s.Config.TrackTerminations {
+				if sink := sinkName(pkg, t); sink != "" {
+					node.Value.Sinks = appendUnique(node.Value.Sinks, sink)
+				}
+			}
+			if s.Config.TrackLocks {
+				if lock, method := lockCall(pkg, t); lock != "" {
+					switch method {
+					case "Lock", "RLock":
+						node.Value.Locks = append(node.Value.Locks, LockAcquire{Lock: lock, Held: held})
+						held = append(held[:len(held):len(held)], lock)
+					case "Unlock", "RUnlock":
+						if !deferred[t] { // released at the return, if deferred
+							for i := len(held) - 1; i >= 0; i-- {
+								if held[i] == lock {
+									held = append(held[:i:i], held[i+1:]...)
+									break
+								}
+							}
 						}
-						path := p.Path()
-						if _, ok := s.pkgMap[path]; !ok {
-							return true
+					}
+				}
+			}
+			if child := s.callee(pkg, t); child != nil {
+				s.link(node, child)
+				if s.Config.TrackComm && inGoroutine(stack) {
+					node.Value.Spawns = appendUnique(node.Value.Spawns, child.Value.ID)
+				}
+				if len(held) > 0 && !inGoroutine(stack) { // the goroutine does not hold the locks of the caller
+					if node.Value.HeldLocks == nil {
+						node.Value.HeldLocks = map[string][]string{}
+					}
+					for _, lock := range held {
+						node.Value.HeldLocks[child.Value.ID] = appendUnique(node.Value.HeldLocks[child.Value.ID], lock)
+					}
+				}
+				if s.Config.TrackContexts {
+					if expr := newContextArg(pkg, t, child); expr != "" {
+						if node.Value.NewContexts == nil {
+							node.Value.NewContexts = map[string]string{}
 						}
-						id := path + "." + named.Obj().Name() + "#" + fn.Name()
-						subject := &Subject{Object: fn, ID: id, Recv: named.Obj().Name(), Kind: KindMethod}
-						child := s.g.Madd(subject)
-						child.Name = fn.Name()
-						s.g.LinkTo(node, child)
+						node.Value.NewContexts[child.Value.ID] = expr
 					}
-				} else {
-					// invoke function <pkg>.<name>()
-					switch x := sym.X.(type) {
-					case *ast.Ident:
-						if impath, ok := f.ImportPath(x.Name); ok {
-							if impkg, ok := s.pkgMap[impath]; ok {
-								ob := impkg.Types.Scope().Lookup(sym.Sel.Name)
-								subject := &Subject{Object: ob, ID: impkg.ID + "." + sym.Sel.Name, Kind: KindFunc}
-								child := s.g.Madd(subject)
-								child.Name = sym.Sel.Name
-								s.g.LinkTo(node, child)
+				}
+				if s.Config.TrackErrors && returnsError(child) {
+					if node.Value.ErrorHandling == nil {
+						node.Value.ErrorHandling = map[string][]ErrorHandling{}
+					}
+					h := errorHandling(pkg, node, stack)
+					node.Value.ErrorHandling[child.Value.ID] = appendUnique(node.Value.ErrorHandling[child.Value.ID], h)
+				}
+			}
+
+			if s.Config.TrackEntryPoints {
+				s.scanRegistration(pkg, t)
+			}
+			if s.Config.IncludeReferences {
+				for _, arg := range t.Args {
+					s.scanFuncValue(pkg, node, arg)
+				}
+			}
+		case *ast.CompositeLit:
+			// T{<field>: <value>}, map[K]V{<key>: <value>}, []T{<value>}
+			if s.Config.TrackEntryPoints {
+				if typ := pkg.TypesInfo.TypeOf(t); typ != nil {
+					for _, elt := range t.Elts {
+						if kv, ok := elt.(*ast.KeyValueExpr); ok {
+							if key, ok := kv.Key.(*ast.Ident); ok {
+								s.scanRegistrationField(pkg, typ, key.Name, kv.Value)
 							}
 						}
 					}
 				}
-			case *ast.Ident:
-				// <name>()
-				if ob, ok := pkg.TypesInfo.Uses[sym]; ok {
-					if ob.Pkg() != nil { // skip stdlib
-						subject := &Subject{ID: pkg.ID + "." + sym.Name, Object: ob, Kind: KindFunc}
-						child := s.g.Madd(subject)
-						child.Name = sym.Name
-						s.g.LinkTo(node, child)
+			}
+			if s.Config.IncludeReferences {
+				for _, elt := range t.Elts {
+					if kv, ok := elt.(*ast.KeyValueExpr); ok {
+						elt = kv.Value
 					}
+					s.scanFuncValue(pkg, node, elt)
 				}
 			}
 		}
 		return true
 	})
+}
+
+// callee returns the node of the function or method called by call, or nil if it is not included in the graph.
+func (s *Scanner) callee(pkg *packages.Package, call *ast.CallExpr) *Node {
+	switch sym := call.Fun.(type) {
+	case *ast.SelectorExpr:
+		// <x>.<sel>
+		if selection, ok := pkg.TypesInfo.Selections[sym]; ok {
+			// invoke method <object>.<name>()
+			fn := selection.Obj()
+			recvType := selection.Recv()
+			if t, ok := recvType.(*types.Pointer); ok {
+				recvType = t.Elem()
+			}
+			if named, ok := recvType.(*types.Named); ok {
+				p := fn.Pkg()
+				if p == nil {
+					return nil
+				}
+				path := p.Path()
+				if !s.needPkg(path, fn) {
+					return nil
+				}
+				id := path + "." + named.Obj().Name() + "#" + fn.Name()
+				subject := &Subject{Object: fn, ID: id, Recv: named.Obj().Name(), Kind: KindMethod}
+				child := s.add(subject)
+				child.Name = fn.Name()
+				return child
+			}
+		} else {
+			// invoke function <pkg>.<name>()
+			switch x := sym.X.(type) {
+			case *ast.Ident:
+				if pkgname, ok := pkg.TypesInfo.Uses[x].(*types.PkgName); ok {
+					ob := pkg.TypesInfo.Uses[sym.Sel]
+					if ob == nil {
+						return nil // undefined (KeepGoing)
+					}
+					if path := pkgname.Imported().Path(); s.needPkg(path, ob) {
+						subject := &Subject{Object: ob, ID: path + "." + sym.Sel.Name, Kind: KindFunc}
+						child := s.add(subject)
+						child.Name = sym.Sel.Name
+						return child
+					}
+				}
+			}
+		}
+	case *ast.Ident:
+		// <name>()
+		if ob, ok := pkg.TypesInfo.Uses[sym]; ok {
+			if ob.Pkg() != nil { // skip stdlib
+				id := pkg.PkgPath + "." + sym.Name
+				if ob.Pkg() != pkg.Types {
+					// dot-imported <name>()
+					path := ob.Pkg().Path()
+					if !s.needPkg(path, ob) {
+						return nil
+					}
+					id = path + "." + sym.Name
+				}
+				subject := &Subject{ID: id, Object: ob, Kind: KindFunc}
+				child := s.add(subject)
+				child.Name = sym.Name
+				return child
+			}
+		}
+	}
 	return nil
 }
 
+// scanFuncValue links node to the function or method used as a value by expr, as a reference (e.g. sort.Slice(xs, less), http.HandleFunc("/", h.Serve)).
+func (s *Scanner) scanFuncValue(pkg *packages.Package, node *Node, expr ast.Expr) {
+	child := s.funcValue(pkg, expr)
+	if child == nil {
+		return
+	}
+	if s.linkTo(node, child) {
+		if node.Value.Refs == nil {
+			node.Value.Refs = map[string]bool{}
+		}
+		node.Value.Refs[child.Value.ID] = true
+	}
+}
+
+// funcValue returns the node of the function or method used as a value by expr, or nil if it is not included in the graph.
+func (s *Scanner) funcValue(pkg *packages.Package, expr ast.Expr) *Node {
+	var fn *types.Func
+	var recv *types.Named
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		// <name>
+		fn, _ = pkg.TypesInfo.Uses[expr].(*types.Func)
+	case *ast.SelectorExpr:
+		if selection, ok := pkg.TypesInfo.Selections[expr]; ok {
+			// <x>.<method> or (<type>).<method>
+			if selection.Kind() == types.FieldVal {
+				return nil
+			}
+			fn, _ = selection.Obj().(*types.Func)
+			recvType := selection.Recv()
+			if t, ok := recvType.(*types.Pointer); ok {
+				recvType = t.Elem()
+			}
+			if recv, ok = recvType.(*types.Named); !ok {
+				return nil
+			}
+		} else {
+			// <pkg>.<name>
+			fn, _ = pkg.TypesInfo.Uses[expr.Sel].(*types.Func)
+		}
+	}
+	if fn == nil || fn.Pkg() == nil {
+		return nil
+	}
+	path := fn.Pkg().Path()
+	if !s.needPkg(path, fn) {
+		return nil
+	}
+
+	if recv != nil {
+		return s.methodNode(fn, recv)
+	}
+	child := s.add(&Subject{ID: path + "." + fn.Name(), Object: fn, Kind: KindFunc})
+	child.Name = fn.Name()
+	return child
+}
+
+// methodNode returns the node of the method of recv.
+func (s *Scanner) methodNode(fn *types.Func, recv *types.Named) *Node {
+	child := s.add(&Subject{ID: fn.Pkg().Path() + "." + recv.Obj().Name() + "#" + fn.Name(), Object: fn, Recv: recv.Obj().Name(), Kind: KindMethod})
+	child.Name = fn.Name()
+	return child
+}
+
+// needPkg reports whether ob in the package is included in the graph.
+// If the package is not loaded, the function or method is treated as an external leaf node (Config.ShowExternal).
+func (s *Scanner) needPkg(path string, ob types.Object) bool {
+	if s.pkgMap[path] {
+		return true
+	}
+	if _, ok := ob.(*types.Func); ok && s.Config.NeedExternal(path) {
+		return true
+	}
+	return false
+}
+
+// add adds the node of subject, and tags it with the current build configuration.
+func (s *Scanner) add(subject *Subject) *Node {
+	node := s.g.Madd(subject)
+	if s.build != "" {
+		node.Value.Builds = appendUnique(node.Value.Builds, s.build)
+	}
+	return node
+}
+
+// linkTo links prev to node, and tags the edge with the current build configuration.
+func (s *Scanner) linkTo(prev *Node, node *Node) (added bool) {
+	added = s.g.LinkTo(prev, node)
+	if s.build != "" {
+		if prev.Value.EdgeBuilds == nil {
+			prev.Value.EdgeBuilds = map[string][]string{}
+		}
+		prev.Value.EdgeBuilds[node.Value.ID] = appendUnique(prev.Value.EdgeBuilds[node.Value.ID], s.build)
+	}
+	return added
+}
+
+func appendUnique[T comparable](xs []T, x T) []T {
+	for _, y := range xs {
+		if x == y {
+			return xs
+		}
+	}
+	return append(xs, x)
+}
+
+// link links node to the called child. if child is already linked as a reference, it is treated as a call.
+func (s *Scanner) link(node *Node, child *Node) {
+	s.linkTo(node, child)
+	delete(node.Value.Refs, child.Value.ID)
+}
+
 func (s *Scanner) scanTypeSpec(pkg *packages.Package, f *file, spec *ast.TypeSpec) error {
 	// type <name> = <type>
 	// type <name> <type>
@@ -164,35 +531,33 @@ func (s *Scanner) scanTypeSpec(pkg *packages.Package, f *file, spec *ast.TypeSpe
 	// type <name> interface { ... }
 
 	ob := pkg.TypesInfo.Defs[spec.Name]
-	subject := &Subject{ID: pkg.ID + "." + spec.Name.Name, Object: ob, Kind: KindObject}
-	node := s.g.Madd(subject)
+	if ob == nil {
+		return nil // broken
+	}
+	subject := &Subject{ID: pkg.PkgPath + "." + spec.Name.Name, Object: ob, Kind: KindObject}
+	node := s.add(subject)
 	node.Name = spec.Name.Name
-
+	node.Value.Decl = spec
+	if s.hasErrors(pkg, spec) {
+		node.Value.Incomplete = true
+	}
 	return nil
 }
 
-type file struct {
-	t       *ast.File
-	imports map[string]string // name -> path
-	sync.Once
-}
-
-func (f *file) ImportPath(name string) (string, bool) {
-	f.Once.Do(func() {
-		imports := make(map[string]string, len(f.t.Imports))
-		for _, im := range f.t.Imports {
-			path, _ := strconv.Unquote(im.Path.Value)
-			name := ""
-			if im.Name != nil {
-				name = im.Name.Name
-			} else {
-				parts := strings.Split(path, "/") // TODO: this has bug (e.g. go-sqlite)
-				name = parts[len(parts)-1]
-			}
-			imports[name] = path
+// hasErrors reports whether the declaration includes the position of errors.
+func (s *Scanner) hasErrors(pkg *packages.Package, decl ast.Node) bool {
+	if len(s.errors) == 0 {
+		return false
+	}
+	start, end := pkg.Fset.Position(decl.Pos()), pkg.Fset.Position(decl.End())
+	for _, line := range s.errors[start.Filename] {
+		if start.Line <= line && line <= end.Line {
+			return true
 		}
-		f.imports = imports
-	})
-	path, ok := f.imports[name]
-	return path, ok
+	}
+	return false
+}
+
+type file struct {
+	t *ast.File
 }
@@ -0,0 +1,263 @@
+package goinspect
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Server serves the scanned graph as a JSON API, and a small explorer UI.
+//
+//	GET /                                        the explorer UI
+//	GET /api/nodes?q=<name>                      {"nodes": [<node>]}, filtered by the name, if q is given
+//	GET /api/nodes/<id>                          <node>
+//	GET /api/nodes/<id>/callees                  {"nodes": [<node>]}, the nodes called by the node
+//	GET /api/nodes/<id>/callers                  {"nodes": [<node>]}, the nodes calling the node
+//	GET /api/nodes/<id>/source                   {"id": <id>, "pos": "<filename>:<line>", "source": "<text>"}
+//	GET /api/paths?from=<id>&to=<id>&limit=<n>   {"paths": [[<id>]]}, the call paths between the nodes (limit is 10, by default)
+//
+// <node> is the same as the node of --format json. On error, {"error": "<message>"} is returned with the status code.
+type Server struct {
+	Config *Config
+	Graph  *Graph
+
+	nodes  map[int]*Node
+	prefix string
+
+	mu      sync.Mutex // the requests are served concurrently, and sourceCache is not safe for it
+	sources *sourceCache
+}
+
+// NewServer returns the server of the scanned graph.
+func NewServer(c *Config, g *Graph) *Server {
+	s := &Server{Config: c, Graph: g, nodes: make(map[int]*Node, len(g.Nodes)), prefix: textPrefix(c.PkgPath), sources: newSourceCache()}
+	for _, n := range g.Nodes {
+		s.nodes[n.ID] = n
+	}
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.error(w, http.StatusMethodNotAllowed, fmt.Errorf("method not allowed, %s", r.Method))
+		return
+	}
+
+	switch path := r.URL.Path; {
+	case path == "/":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, serverUI)
+	case path == "/api/nodes":
+		s.handleNodes(w, r)
+	case path == "/api/paths":
+		s.handlePaths(w, r)
+	case strings.HasPrefix(path, "/api/nodes/"):
+		// /api/nodes/<id>[/<action>]
+		id, action, _ := strings.Cut(strings.TrimPrefix(path, "/api/nodes/"), "/")
+		n, err := s.node(id)
+		if err != nil {
+			s.error(w, http.StatusNotFound, err)
+			return
+		}
+		switch action {
+		case "":
+			s.json(w, newJSONNode(s.Config, n, s.prefix, s.need))
+		case "callees":
+			s.json(w, map[string][]jsonNode{"nodes": s.jsonNodes(n.To)})
+		case "callers":
+			s.json(w, map[string][]jsonNode{"nodes": s.jsonNodes(n.From)})
+		case "source":
+			jn := newJSONNode(s.Config, n, s.prefix, s.need)
+			s.mu.Lock()
+			source := s.sources.text(s.Config, n.Value.Decl)
+			s.mu.Unlock()
+			s.json(w, map[string]interface{}{"id": n.ID, "pos": jn.Pos, "source": source})
+		default:
+			s.error(w, http.StatusNotFound, fmt.Errorf("not found, %s", path))
+		}
+	default:
+		s.error(w, http.StatusNotFound, fmt.Errorf("not found, %s", path))
+	}
+}
+
+func (s *Server) handleNodes(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	var nodes []*Node
+	for _, n := range s.Graph.Nodes {
+		if q == "" || strings.Contains(n.Name, q) {
+			nodes = append(nodes, n)
+		}
+	}
+	s.json(w, map[string][]jsonNode{"nodes": s.jsonNodes(nodes)})
+}
+
+func (s *Server) handlePaths(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	from, err := s.node(query.Get("from"))
+	if err != nil {
+		s.error(w, http.StatusBadRequest, fmt.Errorf("from: %w", err))
+		return
+	}
+	to, err := s.node(query.Get("to"))
+	if err != nil {
+		s.error(w, http.StatusBadRequest, fmt.Errorf("to: %w", err))
+		return
+	}
+	limit := 10
+	if v := query.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			s.error(w, http.StatusBadRequest, fmt.Errorf("limit: invalid value %q", v))
+			return
+		}
+	}
+	s.json(w, map[string][][]int{"paths": findPaths(from, to, limit, s.need)})
+}
+
+// findPaths returns the call paths from a node to another node through the needed nodes, at most limit paths.
+func findPaths(from *Node, to *Node, limit int, need func(*Node) bool) [][]int {
+	// the nodes reaching to
+	reach := map[int]bool{to.ID: true}
+	q := []*Node{to}
+	for len(q) > 0 {
+		var n *Node
+		n, q = q[0], q[1:]
+		for _, prev := range n.From {
+			if !reach[prev.ID] && need(prev) {
+				reach[prev.ID] = true
+				q = append(q, prev)
+			}
+		}
+	}
+
+	paths := [][]int{}
+	onPath := map[int]bool{}
+	var walk func(n *Node, path []int)
+	walk = func(n *Node, path []int) {
+		if len(paths) >= limit || onPath[n.ID] || !reach[n.ID] {
+			return
+		}
+		path = append(path, n.ID)
+		if n.ID == to.ID {
+			paths = append(paths, append([]int{}, path...))
+			return
+		}
+		onPath[n.ID] = true
+		for _, next := range n.To {
+			walk(next, path)
+		}
+		onPath[n.ID] = false
+	}
+	walk(from, nil)
+	return paths
+}
+
+func (s *Server) node(id string) (*Node, error) {
+	i, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node id %q", id)
+	}
+	n, ok := s.nodes[i]
+	if !ok || !s.need(n) {
+		return nil, fmt.Errorf("node is not found, %d", i)
+	}
+	return n, nil
+}
+
+func (s *Server) need(n *Node) bool {
+	return s.Config.NeedNode(n)
+}
+
+func (s *Server) jsonNodes(nodes []*Node) []jsonNode {
+	r := []jsonNode{}
+	for _, n := range nodes {
+		if s.need(n) {
+			r = append(r, newJSONNode(s.Config, n, s.prefix, s.need))
+		}
+	}
+	return r
+}
+
+func (s *Server) json(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func (s *Server) error(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+const serverUI = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>goinspect</title>
+<style>
+body { font-family: sans-serif; margin: 0; display: flex; height: 100vh; }
+#list { width: 22em; overflow: auto; border-right: 1px solid #ccc; padding: .5em; }
+#main { flex: 1; overflow: auto; padding: .5em 1em; }
+#graph { display: flex; gap: 1em; align-items: flex-start; }
+#graph > div { flex: 1; }
+.node { font-family: monospace; cursor: pointer; padding: .1em .3em; }
+.node:hover { background: #eef; }
+.current { font-family: monospace; font-weight: bold; padding: .1em .3em; border: 2px solid #36c; }
+.pos { color: #888; font-size: smaller; }
+pre { background: #f6f6f6; padding: .5em; overflow: auto; }
+</style>
+</head>
+<body>
+<div id="list"><input id="q" type="search" placeholder="search by name" autofocus><div id="nodes"></div></div>
+<div id="main"><div id="graph"></div><div class="pos" id="pos"></div><pre id="source"></pre></div>
+<script>
+function get(path) { return fetch(path).then(function (res) { return res.json(); }); }
+function item(n) {
+  var el = document.createElement("div");
+  el.className = "node";
+  el.textContent = n.text;
+  el.onclick = function () { show(n.id); };
+  return el;
+}
+function column(title, nodes) {
+  var el = document.createElement("div");
+  var h = document.createElement("h3");
+  h.textContent = title + " (" + nodes.length + ")";
+  el.appendChild(h);
+  nodes.forEach(function (n) { el.appendChild(item(n)); });
+  return el;
+}
+function show(id) {
+  Promise.all([get("/api/nodes/" + id), get("/api/nodes/" + id + "/callers"), get("/api/nodes/" + id + "/callees"), get("/api/nodes/" + id + "/source")]).then(function (r) {
+    var graph = document.getElementById("graph");
+    graph.innerHTML = "";
+    graph.appendChild(column("callers", r[1].nodes));
+    var center = column("node", []);
+    var current = document.createElement("div");
+    current.className = "current";
+    current.textContent = r[0].text;
+    center.appendChild(current);
+    graph.appendChild(center);
+    graph.appendChild(column("callees", r[2].nodes));
+    document.getElementById("pos").textContent = r[3].pos || "";
+    document.getElementById("source").textContent = r[3].source || "";
+  });
+}
+function search() {
+  get("/api/nodes?q=" + encodeURIComponent(document.getElementById("q").value)).then(function (r) {
+    var el = document.getElementById("nodes");
+    el.innerHTML = "";
+    r.nodes.forEach(function (n) { el.appendChild(item(n)); });
+  });
+}
+document.getElementById("q").addEventListener("input", search);
+search();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,134 @@
+package goinspect
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Termination is the witness that a function may terminate the process, or panic.
+type Termination struct {
+	Sink string  // e.g. panic, os.Exit, log.Fatalf, log.Logger#Fatal
+	Path []*Node // the call path to the function calling Sink directly, including the function itself
+}
+
+// sinkName returns the name of the terminating function called by call (e.g. panic, os.Exit), or "".
+func sinkName(pkg *packages.Package, call *ast.CallExpr) string {
+	var fn *types.Func
+	switch sym := unparen(call.Fun).(type) {
+	case *ast.Ident:
+		switch ob := pkg.TypesInfo.Uses[sym].(type) {
+		case *types.Builtin:
+			if ob.Name() == "panic" {
+				return "panic"
+			}
+			return ""
+		case *types.Func:
+			fn = ob // dot-imported
+		}
+	case *ast.SelectorExpr:
+		fn, _ = pkg.TypesInfo.Uses[sym.Sel].(*types.Func)
+	}
+	if fn == nil || fn.Pkg() == nil {
+		return ""
+	}
+
+	name := fn.Name()
+	switch fn.Pkg().Path() {
+	case "os":
+		if name == "Exit" {
+			return "os.Exit"
+		}
+	case "log":
+		if !strings.HasPrefix(name, "Fatal") && !strings.HasPrefix(name, "Panic") {
+			return ""
+		}
+		if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+			return "log.Logger#" + name
+		}
+		return "log." + name
+	}
+	return ""
+}
+
+// ApplyTerminations sets the witness of the functions reaching the terminating calls matched by patterns (Subject.Termination).
+// The patterns are globs of the sink names (e.g. os.Exit, log.Fatal*). If patterns is empty, all sinks are matched.
+// The witness is the shortest call path, the edges of the references (not called) are not followed.
+func ApplyTerminations(g *Graph, patterns []string) {
+	match := func(sink string) bool {
+		if len(patterns) == 0 {
+			return true
+		}
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, sink); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	var q []*Node
+	for _, n := range g.Nodes {
+		n.Value.Termination = nil
+		for _, sink := range n.Value.Sinks {
+			if match(sink) {
+				n.Value.Termination = &Termination{Sink: sink, Path: []*Node{n}}
+				q = append(q, n)
+				break
+			}
+		}
+	}
+	for len(q) > 0 {
+		var n *Node
+		n, q = q[0], q[1:]
+		for _, prev := range n.From {
+			if prev.Value.Termination != nil || prev.Value.Kind == KindObject || prev.Value.Refs[n.Value.ID] {
+				continue
+			}
+			path := append([]*Node{prev}, n.Value.Termination.Path...)
+			prev.Value.Termination = &Termination{Sink: n.Value.Termination.Sink, Path: path}
+			q = append(q, prev)
+		}
+	}
+}
+
+// DumpTerminations dumps the functions that may terminate the process (or panic), with the call paths (e.g. func x.F() -> func x.G() -> os.Exit).
+func DumpTerminations(w io.Writer, c *Config, g *Graph) error {
+	prefix := textPrefix(c.PkgPath)
+	var lines []string
+	for _, n := range g.Nodes {
+		t := n.Value.Termination
+		if t == nil || !c.NeedNode(n) {
+			continue
+		}
+		texts := make([]string, 0, len(t.Path)+1)
+		for _, x := range t.Path {
+			texts = append(texts, nodeText(c, x, prefix))
+		}
+		lines = append(lines, strings.Join(append(texts, t.Sink), " -> "))
+	}
+	sort.Strings(lines)
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// terminationText returns the annotation of the node, "!calls:<sink>" if it calls the sink directly, "!reaches:<sink>" if transitively.
+func terminationText(t *Termination) string {
+	if t == nil {
+		return ""
+	}
+	if len(t.Path) == 1 {
+		return "!calls:" + t.Sink
+	}
+	return "!reaches:" + t.Sink
+}
@@ -0,0 +1,429 @@
+package goinspect
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// TUI is an interactive browser of the call tree, drawn with plain terminal escape codes.
+// The input is expected to be in raw mode (e.g. stty raw -echo).
+type TUI struct {
+	Config *Config
+	Graph  *Graph
+
+	Width  int // the number of columns of the screen, if 0, lines are not truncated
+	Height int // the number of lines of the screen
+
+	Open func(filename string, line int) error // opens the source of the selected node (e.g. $EDITOR)
+
+	nodes map[int]*Node
+	views []*tuiView // the stack of views, the last one is shown
+
+	searching bool
+	input     string // the query being typed
+	query     string // the last query
+	message   string
+}
+
+type tuiView struct {
+	title      string
+	rows       []*row
+	sameIDRows map[int][]*row
+	expanded   map[int]bool // row index -> expanded
+	cursor     int          // row index
+	offset     int          // the first shown line in the visible rows
+	jumped     map[int]int  // row id -> the index of the last jumped reference in sameIDRows
+}
+
+const tuiHelp = "j/k:move l/h:open/close enter:toggle *:jump c/r:callees/callers b:back /:search n:next e:edit q:quit"
+
+// NewTUI returns the browser of the call tree reaching nodes. If nodes is empty, all nodes are browsed.
+func NewTUI(c *Config, g *Graph, nodes []*Node) *TUI {
+	t := &TUI{Config: c, Graph: g, Height: 24, nodes: make(map[int]*Node, len(g.Nodes))}
+	for _, n := range g.Nodes {
+		t.nodes[n.ID] = n
+	}
+
+	title := "package " + strings.Join(c.Targets(), ", ")
+	var rows []*row
+	var sameIDRows map[int][]*row
+	if len(nodes) == 0 {
+		rows, sameIDRows = collectRows(c, g, g.Nodes, nil, isRoot)
+	} else {
+		selected, seen := selectNodes(g, nodes)
+		rows, sameIDRows = collectRows(c, g, selected, seen, isRoot)
+	}
+	t.views = []*tuiView{{title: title, rows: rows, sameIDRows: sameIDRows, expanded: map[int]bool{}}}
+	return t
+}
+
+// Run reads keys from in and draws the screen to out, until "q" is pressed or in is closed.
+func (t *TUI) Run(in io.Reader, out io.Writer) error {
+	r := bufio.NewReader(in)
+	if _, err := io.WriteString(out, "\x1b[?25l"); err != nil { // hide cursor
+		return err
+	}
+	defer io.WriteString(out, "\x1b[?25h\x1b[H\x1b[2J") // show cursor, and clear screen
+
+	for {
+		if err := t.render(out); err != nil {
+			return err
+		}
+		key, err := readKey(r)
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if quit := t.handle(key); quit {
+			return nil
+		}
+	}
+}
+
+// readKey reads a key. the escape sequences of arrow keys are returned as "up", "down", "right" and "left".
+func readKey(r *bufio.Reader) (string, error) {
+	ch, _, err := r.ReadRune()
+	if err != nil {
+		return "", err
+	}
+	switch ch {
+	case '\x1b':
+		if r.Buffered() < 2 {
+			return "escape", nil
+		}
+		if next, _ := r.Peek(1); next[0] != '[' {
+			return "escape", nil
+		}
+		r.ReadByte()
+		b, _ := r.ReadByte()
+		switch b {
+		case 'A':
+			return "up", nil
+		case 'B':
+			return "down", nil
+		case 'C':
+			return "right", nil
+		case 'D':
+			return "left", nil
+		}
+		return "escape", nil
+	case '\r', '\n':
+		return "enter", nil
+	case '\x7f', '\b':
+		return "backspace", nil
+	case '\x03':
+		return "ctrl-c", nil
+	}
+	return string(ch), nil
+}
+
+// handle updates the state by key, and reports whether the browser is quitted.
+func (t *TUI) handle(key string) (quit bool) {
+	t.message = ""
+	if t.searching {
+		switch key {
+		case "enter":
+			t.searching = false
+			if t.input != "" {
+				t.query = t.input
+				t.search(false)
+			}
+		case "escape", "ctrl-c":
+			t.searching = false
+		case "backspace":
+			if t.input != "" {
+				runes := []rune(t.input)
+				t.input = string(runes[:len(runes)-1])
+			}
+		default:
+			if runes := []rune(key); len(runes) == 1 && unicode.IsPrint(runes[0]) {
+				t.input += key
+			}
+		}
+		return false
+	}
+
+	v := t.view()
+	switch key {
+	case "q", "ctrl-c":
+		return true
+	case "j", "down":
+		v.move(1)
+	case "k", "up":
+		v.move(-1)
+	case "l", "right":
+		if v.hasChildren(v.cursor) {
+			v.expanded[v.cursor] = true
+		}
+	case "h", "left":
+		if v.expanded[v.cursor] {
+			delete(v.expanded, v.cursor)
+		} else if parent := v.parent(v.cursor); parent >= 0 {
+			v.cursor = parent
+		}
+	case "enter", " ":
+		if v.hasChildren(v.cursor) {
+			v.expanded[v.cursor] = !v.expanded[v.cursor]
+		}
+	case "*":
+		v.jump()
+	case "c", "r":
+		t.push(key == "r")
+	case "b", "backspace":
+		if len(t.views) > 1 {
+			t.views = t.views[:len(t.views)-1]
+		}
+	case "/":
+		t.searching = true
+		t.input = ""
+	case "n":
+		if t.query != "" {
+			t.search(true)
+		}
+	case "e":
+		t.open()
+	}
+	return false
+}
+
+func (t *TUI) view() *tuiView {
+	return t.views[len(t.views)-1]
+}
+
+// push shows the callees (or the callers, if reverse) of the selected node, as a new view.
+func (t *TUI) push(reverse bool) {
+	v := t.view()
+	if len(v.rows) == 0 {
+		return
+	}
+	n := t.nodes[v.rows[v.cursor].id]
+
+	var rows []*row
+	var sameIDRows map[int][]*row
+	var title string
+	if reverse {
+		title = "callers of " + v.rows[v.cursor].text
+		rows, sameIDRows = collectReverseRows(t.Config, []*Node{n})
+	} else {
+		title = "callees of " + v.rows[v.cursor].text
+		rows, sameIDRows = collectRows(t.Config, t.Graph, []*Node{n}, nil, func(*Node) bool { return true })
+	}
+	if len(rows) == 0 {
+		t.message = "not found: " + title
+		return
+	}
+	t.views = append(t.views, &tuiView{title: title, rows: rows, sameIDRows: sameIDRows, expanded: map[int]bool{0: true}})
+}
+
+// search moves the cursor to the next row whose name includes the query.
+func (t *TUI) search(next bool) {
+	v := t.view()
+	start := v.cursor
+	if next {
+		start++
+	}
+	for k := 0; k < len(v.rows); k++ {
+		i := (start + k) % len(v.rows)
+		if strings.Contains(v.rows[i].name, t.query) {
+			v.reveal(i)
+			return
+		}
+	}
+	t.message = fmt.Sprintf("not found: %q", t.query)
+}
+
+// open opens the position of the selected node.
+func (t *TUI) open() {
+	v := t.view()
+	if len(v.rows) == 0 || t.Open == nil || t.Config.Fset == nil {
+		return
+	}
+	n := t.nodes[v.rows[v.cursor].id]
+	pos := t.Config.Fset.Position(n.Value.Object.Pos())
+	if !pos.IsValid() {
+		t.message = "no position: " + v.rows[v.cursor].text
+		return
+	}
+	if err := t.Open(pos.Filename, pos.Line); err != nil {
+		t.message = fmt.Sprintf("open %s:%d: %v", pos.Filename, pos.Line, err)
+	}
+}
+
+func (t *TUI) render(w io.Writer) error {
+	v := t.view()
+	page := t.Height - 2 // the title line and the status line
+	if page < 1 {
+		page = 1
+	}
+	visible := v.visible()
+	pos := 0
+	for k, i := range visible {
+		if i == v.cursor {
+			pos = k
+		}
+	}
+	if pos < v.offset {
+		v.offset = pos
+	} else if pos >= v.offset+page {
+		v.offset = pos - page + 1
+	}
+
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")                           // move to home, and clear screen
+	fmt.Fprintf(&b, "\x1b[1m%s\x1b[0m\r\n", t.clip(v.title)) // bold
+	for k := v.offset; k < len(visible) && k < v.offset+page; k++ {
+		line := t.clip(v.line(t.Config, visible[k]))
+		if visible[k] == v.cursor {
+			line = "\x1b[7m" + line + "\x1b[0m" // reverse video
+		}
+		b.WriteString(line + "\r\n")
+	}
+
+	status := tuiHelp
+	if t.searching {
+		status = "/" + t.input
+	} else if t.message != "" {
+		status = t.message
+	}
+	fmt.Fprintf(&b, "\x1b[%d;1H%s", t.Height, t.clip(status))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (t *TUI) clip(s string) string {
+	if t.Width <= 0 {
+		return s
+	}
+	if runes := []rune(s); len(runes) > t.Width {
+		return string(runes[:t.Width])
+	}
+	return s
+}
+
+// lines returns the visible lines, without escape codes.
+func (t *TUI) lines() []string {
+	v := t.view()
+	visible := v.visible()
+	lines := make([]string, len(visible))
+	for k, i := range visible {
+		lines[k] = v.line(t.Config, i)
+		if i == v.cursor {
+			lines[k] = ">" + lines[k]
+		}
+	}
+	return lines
+}
+
+// visible returns the indexes of the rows, whose ancestors are expanded.
+func (v *tuiView) visible() []int {
+	visible := make([]int, 0, len(v.rows))
+	hidden := 0 // the rows deeper than this indent are hidden, if not 0
+	for i, row := range v.rows {
+		if hidden > 0 && row.indent > hidden {
+			continue
+		}
+		hidden = 0
+		visible = append(visible, i)
+		if !v.expanded[i] {
+			hidden = row.indent
+		}
+	}
+	return visible
+}
+
+func (v *tuiView) line(c *Config, i int) string {
+	row := v.rows[i]
+	marker := "  "
+	if v.hasChildren(i) {
+		if v.expanded[i] {
+			marker = "- "
+		} else {
+			marker = "+ "
+		}
+	}
+	text := strings.Repeat(c.Padding, row.indent-1) + marker + rowText(row)
+	if same := v.sameIDRows[row.id]; len(same) > 1 {
+		if same[0] == row {
+			text += fmt.Sprintf("  // &%d", row.id)
+		} else if row.isRecursive {
+			text += fmt.Sprintf("  // *%d recursion", row.id)
+		} else {
+			text += fmt.Sprintf("  // *%d", row.id)
+		}
+	}
+	return text
+}
+
+func (v *tuiView) hasChildren(i int) bool {
+	return i+1 < len(v.rows) && v.rows[i+1].indent > v.rows[i].indent
+}
+
+// parent returns the index of the parent row, or -1 if the row is toplevel.
+func (v *tuiView) parent(i int) int {
+	for j := i - 1; j >= 0; j-- {
+		if v.rows[j].indent < v.rows[i].indent {
+			return j
+		}
+	}
+	return -1
+}
+
+func (v *tuiView) move(delta int) {
+	visible := v.visible()
+	for k, i := range visible {
+		if i == v.cursor {
+			k += delta
+			if 0 <= k && k < len(visible) {
+				v.cursor = visible[k]
+			}
+			return
+		}
+	}
+}
+
+// reveal expands the ancestors of the row, and moves the cursor to it.
+func (v *tuiView) reveal(i int) {
+	for j := v.parent(i); j >= 0; j = v.parent(j) {
+		v.expanded[j] = true
+	}
+	v.cursor = i
+}
+
+// jump moves the cursor from the "*N" reference to the "&N" definition.
+// On the definition, it moves to the reference next to the last jumped one, in turn.
+func (v *tuiView) jump() {
+	if len(v.rows) == 0 {
+		return
+	}
+	row := v.rows[v.cursor]
+	same := v.sameIDRows[row.id]
+	if len(same) <= 1 {
+		return
+	}
+	if v.jumped == nil {
+		v.jumped = map[int]int{}
+	}
+	target := same[0]
+	if target == row {
+		k := v.jumped[row.id]%(len(same)-1) + 1
+		v.jumped[row.id] = k
+		target = same[k]
+	} else {
+		for k, x := range same {
+			if x == row {
+				v.jumped[row.id] = k
+			}
+		}
+	}
+	for i, x := range v.rows {
+		if x == target {
+			v.reveal(i)
+			return
+		}
+	}
+}